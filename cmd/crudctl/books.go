@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func (a *app) listBooks(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("books list", flag.ContinueOnError)
+	title := flags.String("title", "", "title contains")
+	author := flags.String("author", "", "author contains")
+	trash := flags.Bool("trash", false, "list deleted books instead")
+
+	var tags []string
+	flags.Func("tag", "has the tag, can be repeated", func(tag string) error {
+		if tag = domain.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+
+		return nil
+	})
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	var books []domain.Book
+	var err error
+
+	if *trash {
+		books, err = a.books.GetTrash(ctx)
+	} else {
+		books, err = a.books.GetAll(ctx, domain.BookFilter{
+			Title:    *title,
+			Author:   *author,
+			Tags:     tags,
+			TagsMode: domain.TagsAll,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(books))
+	for _, book := range books {
+		rows = append(rows, []string{
+			strconv.FormatInt(book.ID, 10),
+			book.Title,
+			book.Author,
+			book.ISBN13,
+			book.PublishDate.Format("2006-01-02"),
+			fmt.Sprintf("%.2f (%d)", book.Rating, book.RatingCount),
+			strings.Join(book.Tags, ","),
+		})
+	}
+
+	return a.out.print(books, []string{"ID", "TITLE", "AUTHOR", "ISBN13", "PUBLISHED", "RATING", "TAGS"}, rows)
+}
+
+type importFailure struct {
+	Item  int    `json:"item"`
+	Title string `json:"title"`
+	Error string `json:"error"`
+}
+
+// importBooks creates the books read from a JSON array or from NDJSON, as
+// written by "books export". IDs in the input are ignored. A book that
+// fails to be created doesn't stop the import; failures are reported at the
+// end.
+func (a *app) importBooks(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("books import", flag.ContinueOnError)
+	file := flags.String("file", "-", "file to read, - for stdin")
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	in, err := openInput(*file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var imported int
+	failures := make([]importFailure, 0)
+
+	err = decodeBooks(in, func(item int, book domain.Book) error {
+		book.ID = 0
+
+		if err := a.books.Create(ctx, book); err != nil {
+			failures = append(failures, importFailure{Item: item, Title: book.Title, Error: err.Error()})
+			return nil
+		}
+
+		imported++
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "item %d (%s): %s\n", f.Item, f.Title, f.Error)
+	}
+
+	if err := a.out.print(map[string]interface{}{
+		"imported": imported,
+		"failed":   failures,
+	}, []string{"IMPORTED", "FAILED"}, [][]string{{strconv.Itoa(imported), strconv.Itoa(len(failures))}}); err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d books failed to import", len(failures), imported+len(failures))
+	}
+
+	return nil
+}
+
+// decodeBooks calls fn for every book of a JSON array or an NDJSON stream,
+// with the 1-based position of the book in the input.
+func decodeBooks(r io.Reader, fn func(int, domain.Book) error) error {
+	br := bufio.NewReader(r)
+
+	first, err := peekNonSpace(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		return err
+	}
+
+	dec := json.NewDecoder(br)
+
+	if first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	for item := 1; dec.More(); item++ {
+		var book domain.Book
+		if err := dec.Decode(&book); err != nil {
+			return fmt.Errorf("item %d: %w", item, err)
+		}
+
+		if err := fn(item, book); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+func (a *app) exportBooks(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("books export", flag.ContinueOnError)
+	format := flags.String("format", "ndjson", "ndjson or json")
+	file := flags.String("file", "-", "file to write, - for stdout")
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *format != string(domain.ExportNDJSON) && *format != string(domain.ExportJSON) {
+		return fmt.Errorf("unsupported export format %q", *format)
+	}
+
+	out, err := openOutput(*file)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(out)
+	asArray := *format == string(domain.ExportJSON)
+
+	if asArray {
+		w.WriteString("[")
+	}
+
+	written := 0
+	err = a.books.Export(ctx, domain.BookFilter{}, func(book domain.Book) error {
+		b, err := json.Marshal(book)
+		if err != nil {
+			return err
+		}
+
+		if asArray && written > 0 {
+			w.WriteString(",")
+		}
+
+		w.Write(b)
+		if !asArray {
+			w.WriteString("\n")
+		}
+
+		written++
+
+		return nil
+	})
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	if asArray {
+		w.WriteString("]\n")
+	}
+
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return os.Open(name)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func openOutput(name string) (io.WriteCloser, error) {
+	if name == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+
+	return os.Create(name)
+}
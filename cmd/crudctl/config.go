@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// secretFields are masked when printing the config.
+var secretFields = map[string]bool{
+	"Password":  true,
+	"AccessKey": true,
+	"SecretKey": true,
+}
+
+type configEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// printConfig shows the configuration after the config file and the
+// environment have been merged, keyed the way they are set: main.yml keys
+// for values from the file, variable names for values from the environment.
+func (a *app) printConfig() error {
+	var entries []configEntry
+	flattenConfig("", reflect.ValueOf(*a.cfg), &entries)
+
+	values := make(map[string]interface{}, len(entries))
+	rows := make([][]string, 0, len(entries))
+
+	for _, e := range entries {
+		values[e.Key] = e.Value
+		rows = append(rows, []string{e.Key, fmt.Sprint(e.Value)})
+	}
+
+	return a.out.print(values, []string{"KEY", "VALUE"}, rows)
+}
+
+func flattenConfig(prefix string, v reflect.Value, entries *[]configEntry) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		key := configKey(field)
+		if prefix != "" && field.Tag.Get("envconfig") == "" {
+			key = prefix + "." + key
+		}
+
+		if value.Kind() == reflect.Struct {
+			flattenConfig(key, value, entries)
+			continue
+		}
+
+		var out interface{} = value.Interface()
+		if stringer, ok := out.(fmt.Stringer); ok {
+			out = stringer.String()
+		}
+
+		if secretFields[field.Name] && !value.IsZero() {
+			out = "********"
+		}
+
+		*entries = append(*entries, configEntry{Key: key, Value: out})
+	}
+}
+
+func configKey(field reflect.StructField) string {
+	if tag := field.Tag.Get("envconfig"); tag != "" {
+		return tag
+	}
+
+	if tag := field.Tag.Get("mapstructure"); tag != "" {
+		return tag
+	}
+
+	return strings.ToLower(field.Name)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printer writes command results either as an aligned table for people or
+// as JSON for scripts.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "table":
+		return &printer{w: w}, nil
+	case "json":
+		return &printer{w: w, json: true}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// print outputs v as JSON, or the header and rows as a table.
+func (p *printer) print(v interface{}, header []string, rows [][]string) error {
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
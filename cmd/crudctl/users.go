@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+
+	"github.com/crud-app/internal/domain"
+)
+
+var userHeader = []string{"ID", "NAME", "EMAIL", "ROLE", "REGISTERED"}
+
+func (a *app) createUser(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	name := flags.String("name", "", "user name")
+	email := flags.String("email", "", "user email")
+	password := flags.String("password", "", "initial password")
+	role := flags.String("role", string(domain.RoleUser), "user, editor or admin")
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	inp := domain.SignUpInput{Name: *name, Email: *email, Password: *password}
+	if err := inp.Validate(); err != nil {
+		return err
+	}
+
+	user, err := a.users.Create(ctx, inp, domain.Role(*role))
+	if err != nil {
+		return err
+	}
+
+	view := map[string]interface{}{
+		"id":            user.ID,
+		"name":          user.Name,
+		"email":         user.Email,
+		"role":          user.Role,
+		"registered_at": user.RegisteredAt,
+	}
+
+	return a.out.print(view, userHeader, [][]string{{
+		strconv.FormatInt(user.ID, 10),
+		user.Name,
+		user.Email,
+		string(user.Role),
+		user.RegisteredAt.Format("2006-01-02 15:04"),
+	}})
+}
+
+func (a *app) promoteUser(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errUsage
+	}
+
+	role := domain.Role(args[1])
+	if err := a.users.SetRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	return a.out.print(map[string]interface{}{
+		"id":   userID,
+		"role": role,
+	}, []string{"ID", "ROLE"}, [][]string{{args[0], string(role)}})
+}
+
+func (a *app) revokeSessions(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errUsage
+	}
+
+	revoked, err := a.users.RevokeSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return a.out.print(map[string]interface{}{
+		"id":      userID,
+		"revoked": revoked,
+	}, []string{"ID", "REVOKED"}, [][]string{{args[0], strconv.Itoa(revoked)}})
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 
 	"github.com/crud-app/internal/config"
+	"github.com/crud-app/internal/domain"
 	"github.com/crud-app/internal/repository/psql"
 	"github.com/crud-app/internal/service"
+	"github.com/crud-app/internal/transport/graphql"
+	grpctransport "github.com/crud-app/internal/transport/grpc"
 	"github.com/crud-app/internal/transport/rest"
 	"github.com/crud-app/pkg/database"
 	"github.com/crud-app/pkg/hash"
+	"github.com/crud-app/pkg/mail"
+	"github.com/crud-app/pkg/ratelimit"
+	"github.com/crud-app/pkg/realip"
+	"github.com/crud-app/pkg/storage"
 
 	_ "github.com/lib/pq"
 
@@ -51,23 +61,220 @@ func main() {
 	// init deps
 	hasher := hash.NewSHA1Hasher("salt")
 
+	auditRepo := psql.NewAudit(db)
+	auditService := service.NewAudit(auditRepo)
+	transactor := psql.NewTransactor(db)
+
 	booksRepo := psql.NewBooks(db)
-	booksService := service.NewBookManager(booksRepo)
+	revisionsRepo := psql.NewBookRevisions(db)
+	authorsRepo := psql.NewAuthors(db)
+	genresRepo := psql.NewGenres(db)
+	tagsRepo := psql.NewTags(db)
+	webhooksRepo := psql.NewWebhooks(db)
+	bookEvents := service.NewBookEvents(cfg.Events.ReplaySize, cfg.Events.QueueSize)
+	booksService := service.NewBookManager(booksRepo, revisionsRepo, authorsRepo, genresRepo, tagsRepo, auditService,
+		transactor, webhooksRepo, bookEvents)
+	authorsService := service.NewAuthors(authorsRepo, booksService)
+	genresService := service.NewGenres(genresRepo)
+	reviewsService := service.NewReviews(psql.NewReviews(db), booksRepo, auditService)
+	shelvesService := service.NewShelves(psql.NewShelves(db), booksService)
+	readingService := service.NewReading(psql.NewReading(db), booksService)
+
+	blobs, err := newBlobStore(cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	coversService := service.NewCovers(booksRepo, blobs, auditService, cfg.Covers.MaxSize)
+	filesService := service.NewBookFiles(psql.NewBookFiles(db), booksService, blobs, transactor, cfg.Files.MaxSize)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go booksService.RunTrashPurger(ctx, cfg.Books.TrashRetention, cfg.Books.TrashPurgeInterval, coversService,
+		filesService)
+
+	webhooksService := service.NewWebhooks(webhooksRepo, service.WebhooksConfig{
+		PollInterval:    cfg.Webhooks.PollInterval,
+		Timeout:         cfg.Webhooks.Timeout,
+		MaxAttempts:     cfg.Webhooks.MaxAttempts,
+		MinBackoff:      cfg.Webhooks.MinBackoff,
+		MaxBackoff:      cfg.Webhooks.MaxBackoff,
+		OutboxRetention: cfg.Webhooks.OutboxRetention,
+	})
+	go webhooksService.Run(ctx)
 
 	usersRepo := psql.NewUsers(db)
 	tokensRepo := psql.NewTokens(db)
-	usersService := service.NewUsers(usersRepo, tokensRepo, hasher, []byte("sample secret"))
-	handler := rest.NewHandler(booksService, usersService)
+	loginFailuresRepo := psql.NewLoginFailures(db)
+	verificationsRepo := psql.NewEmailVerifications(db)
+	resetsRepo := psql.NewPasswordResets(db)
+	mfaRepo := psql.NewMFA(db)
+
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mfaRoles, err := parseRoles(cfg.Auth.MFA.RequiredRoles)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	usersCfg := service.UsersConfig{
+		Lockout: service.LockoutConfig{
+			DelayAfter:    cfg.Auth.Lockout.DelayAfter,
+			BaseDelay:     cfg.Auth.Lockout.BaseDelay,
+			MaxFailures:   cfg.Auth.Lockout.MaxFailures,
+			IPMaxFailures: cfg.Auth.Lockout.IPMaxFailures,
+			LockDuration:  cfg.Auth.Lockout.LockDuration,
+			Window:        cfg.Auth.Lockout.Window,
+		},
+		Verification: service.VerificationConfig{
+			TokenTTL:        cfg.Auth.Verification.TokenTTL,
+			ResendInterval:  cfg.Auth.Verification.ResendInterval,
+			RequireVerified: cfg.Auth.Verification.RequireVerified,
+			URL:             cfg.Auth.Verification.URL,
+		},
+		PasswordReset: service.PasswordResetConfig{
+			TokenTTL: cfg.Auth.PasswordReset.TokenTTL,
+			Interval: cfg.Auth.PasswordReset.Interval,
+			URL:      cfg.Auth.PasswordReset.URL,
+		},
+		MFA: service.MFAConfig{
+			Issuer:        cfg.Auth.MFA.Issuer,
+			ChallengeTTL:  cfg.Auth.MFA.ChallengeTTL,
+			RequiredRoles: mfaRoles,
+		},
+		DeletionGrace: cfg.Auth.DeletionGrace,
+	}
+
+	usersService := service.NewUsers(usersRepo, tokensRepo, loginFailuresRepo, verificationsRepo, resetsRepo, mfaRepo,
+		hasher, mailer, []byte("sample secret"), auditService, transactor, usersCfg)
+
+	go usersService.RunAccountPurger(ctx, cfg.Auth.DeletionPurgeInterval, filesService)
+
+	rateLimits, err := newRateLimits(cfg.RateLimit, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	proxies, err := realip.ParseProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handler := rest.NewHandler(booksService, usersService, auditService, authorsService, genresService, reviewsService,
+		shelvesService, readingService, coversService, filesService, webhooksService, bookEvents, rateLimits,
+		proxies)
+
+	graphqlHandler, err := graphql.NewHandler(booksService, usersService, reviewsService, graphql.Limits{
+		MaxDepth:              cfg.GraphQL.MaxDepth,
+		MaxComplexity:         cfg.GraphQL.MaxComplexity,
+		MaxIntrospectionDepth: cfg.GraphQL.MaxIntrospectionDepth,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	router := handler.InitRouter()
+	handler.MountGraphQL(router, graphqlHandler)
 
 	// init & run server
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler: handler.InitRouter(),
+		Handler: router,
 	}
 
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	grpcSrv := grpctransport.NewHandler(booksService, usersService, rateLimits, proxies).InitServer()
+
+	go func() {
+		if err := grpcSrv.Serve(grpcListener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
 	log.Info("SERVER STARTED")
 
 	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
 }
+
+func newBlobStore(cfg config.Storage) (service.BlobStore, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return storage.NewLocal(cfg.Dir)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+func newMailer(cfg config.Mail) (service.Mailer, error) {
+	switch cfg.Driver {
+	case "", "file":
+		return mail.NewFileDrop(cfg.Dir, cfg.From)
+	case "smtp":
+		return mail.NewSMTP(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		}), nil
+	case "memory":
+		return mail.NewMemory(cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// parseRoles refuses unknown roles, as a typo in the config would otherwise
+// quietly weaken the policy.
+func parseRoles(names []string) ([]domain.Role, error) {
+	roles := make([]domain.Role, len(names))
+	for i, name := range names {
+		roles[i] = domain.Role(name)
+		if !roles[i].Valid() {
+			return nil, fmt.Errorf("unknown role %q", name)
+		}
+	}
+
+	return roles, nil
+}
+
+func newRateLimits(cfg config.RateLimit, db *sql.DB) (rest.RateLimits, error) {
+	limits := rest.RateLimits{
+		Groups:      make(map[string]ratelimit.Limit, len(cfg.Groups)),
+		MaxInFlight: cfg.MaxInFlight,
+	}
+
+	for group, limit := range cfg.Groups {
+		limits.Groups[group] = ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+
+	switch cfg.Store {
+	case "", "memory":
+		limits.Store = ratelimit.NewMemory()
+	case "postgres":
+		limits.Store = psql.NewRateLimits(db)
+	default:
+		return rest.RateLimits{}, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	return limits, nil
+}
@@ -2,7 +2,13 @@ module github.com/crud-app
 
 go 1.22.0
 
-require github.com/swaggo/gin-swagger v1.6.0
+require (
+	github.com/graph-gophers/dataloader/v7 v7.1.0
+	github.com/graphql-go/graphql v0.8.1
+	github.com/swaggo/gin-swagger v1.6.0
+	golang.org/x/image v0.18.0
+	google.golang.org/grpc v1.65.0
+)
 
 require (
 	github.com/fsnotify/fsnotify v1.7.0 // indirect
@@ -20,6 +26,7 @@ require (
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.9.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 )
 
@@ -70,7 +77,7 @@ require (
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 	golang.org/x/tools v0.30.0 // indirect
-	google.golang.org/protobuf v1.33.0 // indirect
+	google.golang.org/protobuf v1.34.1
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	sigs.k8s.io/yaml v1.4.0 // indirect
@@ -14,11 +14,131 @@ type Config struct {
 
 	Server struct {
 		Port int `mapstructure:"port"`
+		// TrustedProxies are the addresses or CIDR ranges of the reverse
+		// proxies whose X-Forwarded-For header gives the client address.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
 
+	GRPC struct {
+		Port int `mapstructure:"port"`
+	} `mapstructure:"grpc"`
+
+	GraphQL struct {
+		MaxDepth              int `mapstructure:"max_depth"`
+		MaxComplexity         int `mapstructure:"max_complexity"`
+		MaxIntrospectionDepth int `mapstructure:"max_introspection_depth"`
+	} `mapstructure:"graphql"`
+
 	Auth struct {
-		TokenTTL time.Duration `mapstructure:"token_ttl"`
+		TokenTTL              time.Duration `mapstructure:"token_ttl"`
+		DeletionGrace         time.Duration `mapstructure:"deletion_grace"`
+		DeletionPurgeInterval time.Duration `mapstructure:"deletion_purge_interval"`
+
+		Lockout struct {
+			DelayAfter    int           `mapstructure:"delay_after"`
+			BaseDelay     time.Duration `mapstructure:"base_delay"`
+			MaxFailures   int           `mapstructure:"max_failures"`
+			IPMaxFailures int           `mapstructure:"ip_max_failures"`
+			LockDuration  time.Duration `mapstructure:"lock_duration"`
+			Window        time.Duration `mapstructure:"window"`
+		} `mapstructure:"lockout"`
+
+		Verification struct {
+			TokenTTL        time.Duration `mapstructure:"token_ttl"`
+			ResendInterval  time.Duration `mapstructure:"resend_interval"`
+			RequireVerified bool          `mapstructure:"require_verified"`
+			URL             string        `mapstructure:"url"`
+		} `mapstructure:"verification"`
+
+		PasswordReset struct {
+			TokenTTL time.Duration `mapstructure:"token_ttl"`
+			Interval time.Duration `mapstructure:"interval"`
+			URL      string        `mapstructure:"url"`
+		} `mapstructure:"password_reset"`
+
+		MFA struct {
+			Issuer        string        `mapstructure:"issuer"`
+			ChallengeTTL  time.Duration `mapstructure:"challenge_ttl"`
+			RequiredRoles []string      `mapstructure:"required_roles"`
+		} `mapstructure:"mfa"`
 	} `mapstructure:"auth"`
+
+	Mail Mail `mapstructure:"mail"`
+
+	RateLimit RateLimit `mapstructure:"rate_limit"`
+
+	Books struct {
+		TrashRetention     time.Duration `mapstructure:"trash_retention"`
+		TrashPurgeInterval time.Duration `mapstructure:"trash_purge_interval"`
+	} `mapstructure:"books"`
+
+	Events struct {
+		ReplaySize int `mapstructure:"replay_size"`
+		QueueSize  int `mapstructure:"queue_size"`
+	} `mapstructure:"events"`
+
+	Covers struct {
+		MaxSize int64 `mapstructure:"max_size"`
+	} `mapstructure:"covers"`
+
+	Files struct {
+		MaxSize int64 `mapstructure:"max_size"`
+	} `mapstructure:"files"`
+
+	Storage Storage `mapstructure:"storage"`
+
+	Webhooks struct {
+		PollInterval    time.Duration `mapstructure:"poll_interval"`
+		Timeout         time.Duration `mapstructure:"timeout"`
+		MaxAttempts     int           `mapstructure:"max_attempts"`
+		MinBackoff      time.Duration `mapstructure:"min_backoff"`
+		MaxBackoff      time.Duration `mapstructure:"max_backoff"`
+		OutboxRetention time.Duration `mapstructure:"outbox_retention"`
+	} `mapstructure:"webhooks"`
+}
+
+// RateLimit keeps its buckets in "memory", per instance, or in "postgres" to
+// share them between instances. Groups are keyed by route group, with
+// "default" applying to the groups not listed.
+type RateLimit struct {
+	Store       string `mapstructure:"store"`
+	MaxInFlight int    `mapstructure:"max_in_flight"`
+
+	Groups map[string]struct {
+		Rate  float64 `mapstructure:"rate"`
+		Burst int     `mapstructure:"burst"`
+	} `mapstructure:"groups"`
+}
+
+// Mail selects how emails are sent: "smtp", "file" to write them to Dir, or
+// "memory" to drop them.
+type Mail struct {
+	Driver string `mapstructure:"driver"`
+	From   string `mapstructure:"from"`
+	Dir    string `mapstructure:"dir"`
+
+	SMTP struct {
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		Username string `envconfig:"SMTP_USERNAME"`
+		Password string `envconfig:"SMTP_PASSWORD"`
+	} `mapstructure:"smtp"`
+}
+
+// Storage selects where blobs such as cover images are kept: "local" for a
+// directory on disk or "s3" for an S3-compatible service.
+type Storage struct {
+	Driver string `mapstructure:"driver"`
+	Dir    string `mapstructure:"dir"`
+
+	S3 struct {
+		Endpoint  string `mapstructure:"endpoint"`
+		Region    string `mapstructure:"region"`
+		Bucket    string `mapstructure:"bucket"`
+		PathStyle bool   `mapstructure:"path_style"`
+		AccessKey string `envconfig:"S3_ACCESS_KEY"`
+		SecretKey string `envconfig:"S3_SECRET_KEY"`
+	} `mapstructure:"s3"`
 }
 
 type Postgres struct {
@@ -52,7 +172,40 @@ func New(folder, filename string) (*Config, error) {
 		return nil, err
 	}
 
-	fmt.Println("cfg:", cfg)
+	if err := envconfig.Process("", &cfg.Storage.S3); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("", &cfg.Mail.SMTP); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
+
+// validate rejects settings that the background jobs can't run with: a zero
+// interval panics the ticker, and a zero retention would purge right away.
+func (c *Config) validate() error {
+	positive := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"books.trash_retention", c.Books.TrashRetention},
+		{"books.trash_purge_interval", c.Books.TrashPurgeInterval},
+		{"webhooks.poll_interval", c.Webhooks.PollInterval},
+		{"auth.deletion_grace", c.Auth.DeletionGrace},
+		{"auth.deletion_purge_interval", c.Auth.DeletionPurgeInterval},
+	}
+
+	for _, setting := range positive {
+		if setting.value <= 0 {
+			return fmt.Errorf("%s must be a positive duration, got %s", setting.key, setting.value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditBookCreated     AuditAction = "book.created"
+	AuditBookUpdated     AuditAction = "book.updated"
+	AuditBookDeleted     AuditAction = "book.deleted"
+	AuditBookRestored    AuditAction = "book.restored"
+	AuditBookPurged      AuditAction = "book.purged"
+	AuditBookReverted    AuditAction = "book.reverted"
+	AuditBookTagged      AuditAction = "book.tagged"
+	AuditBookUntagged    AuditAction = "book.untagged"
+	AuditBookGenres      AuditAction = "book.genres_set"
+	AuditBookCoverSet    AuditAction = "book.cover_set"
+	AuditBookCoverDrop   AuditAction = "book.cover_removed"
+	AuditReviewHidden    AuditAction = "review.hidden"
+	AuditReviewShown     AuditAction = "review.shown"
+	AuditSignUp          AuditAction = "auth.sign_up"
+	AuditSignIn          AuditAction = "auth.sign_in"
+	AuditSignInFailed    AuditAction = "auth.sign_in_failed"
+	AuditSignInBlocked   AuditAction = "auth.sign_in_blocked"
+	AuditLockedOut       AuditAction = "auth.locked_out"
+	AuditUnlocked        AuditAction = "auth.unlocked"
+	AuditEmailVerified   AuditAction = "auth.email_verified"
+	AuditResetRequested  AuditAction = "auth.password_reset_requested"
+	AuditPasswordReset   AuditAction = "auth.password_reset"
+	AuditPasswordChanged AuditAction = "auth.password_changed"
+	AuditUserUpdated     AuditAction = "user.updated"
+	AuditDeleteRequested AuditAction = "user.delete_requested"
+	AuditDeleteCancelled AuditAction = "user.delete_cancelled"
+	AuditUserPurged      AuditAction = "user.purged"
+	AuditUsersSearched   AuditAction = "user.searched"
+	AuditUserViewed      AuditAction = "user.viewed"
+	AuditUserDisabled    AuditAction = "user.disabled"
+	AuditUserEnabled     AuditAction = "user.enabled"
+	AuditResetForced     AuditAction = "auth.password_reset_forced"
+	AuditMFAEnrolled     AuditAction = "auth.mfa_enrolled"
+	AuditMFAEnabled      AuditAction = "auth.mfa_enabled"
+	AuditMFADisabled     AuditAction = "auth.mfa_disabled"
+	AuditMFAFailed       AuditAction = "auth.mfa_failed"
+	AuditRecoveryUsed    AuditAction = "auth.mfa_recovery_code_used"
+	AuditTokensRefresh   AuditAction = "auth.refresh"
+	AuditSessionsRevoked AuditAction = "auth.sessions_revoked"
+	AuditUserCreated     AuditAction = "user.created"
+	AuditUserRoleSet     AuditAction = "user.role_set"
+)
+
+const (
+	AuditEntityBook   = "book"
+	AuditEntityUser   = "user"
+	AuditEntityReview = "review"
+)
+
+// AuditEntry is a single record of the append-only audit log.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	ActorID   int64           `json:"actor_id,omitempty"`
+	Action    AuditAction     `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id,omitempty"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	ActorID *int64
+	Entity  string
+	Action  AuditAction
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
+
+// Actor describes who is behind the current request. It is put into the
+// request context by the transport layer and read by services that need to
+// attribute their actions, e.g. for auditing.
+type Actor struct {
+	UserID    int64
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type actorCtxKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorCtxKey{}).(Actor)
+	return actor
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+type AuthorRole string
+
+const (
+	AuthorRoleAuthor     AuthorRole = "author"
+	AuthorRoleTranslator AuthorRole = "translator"
+	AuthorRoleEditor     AuthorRole = "editor"
+)
+
+func (r AuthorRole) Valid() bool {
+	switch r {
+	case AuthorRoleAuthor, AuthorRoleTranslator, AuthorRoleEditor:
+		return true
+	}
+
+	return false
+}
+
+type Author struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Bio       string    `json:"bio"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuthorInput struct {
+	Name string `json:"name" validate:"required,gte=1,lte=255"`
+	Bio  string `json:"bio"`
+}
+
+func (i AuthorInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type UpdateAuthorInput struct {
+	Name *string `json:"name" validate:"omitempty,gte=1,lte=255"`
+	Bio  *string `json:"bio"`
+}
+
+func (i UpdateAuthorInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type MergeAuthorsInput struct {
+	From []int64 `json:"from" validate:"required,min=1"`
+}
+
+func (i MergeAuthorsInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// BookAuthor links a book to one of its contributors.
+type BookAuthor struct {
+	AuthorID int64      `json:"author_id"`
+	Name     string     `json:"name,omitempty"`
+	Role     AuthorRole `json:"role"`
+}
+
+// NormalizeAuthorName is the key authors are deduplicated by: case and
+// repeated whitespace are ignored.
+func NormalizeAuthorName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
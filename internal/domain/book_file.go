@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+const (
+	FileTypeEPUB = "application/epub+zip"
+	FileTypePDF  = "application/pdf"
+)
+
+// BookFile is an e-book file a user attached to a book. Files are private to
+// the user who uploaded them. Files with the same content share one blob,
+// addressed by its SHA-256.
+type BookFile struct {
+	ID          int64     `json:"id"`
+	BookID      int64     `json:"book_id"`
+	UserID      int64     `json:"user_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -5,16 +5,138 @@ import (
 )
 
 type Book struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Author      string    `json:"author"`
-	PublishDate time.Time `json:"publish_date"`
-	Rating      int       `json:"rating"`
+	ID              int64           `json:"id"`
+	Title           string          `json:"title"`
+	Author          string          `json:"author"`
+	ISBN10          string          `json:"isbn10,omitempty"`
+	ISBN13          string          `json:"isbn13,omitempty"`
+	PublishDate     time.Time       `json:"publish_date"`
+	Rating          float64         `json:"rating"`
+	RatingCount     int             `json:"rating_count"`
+	RatingHistogram RatingHistogram `json:"rating_histogram"`
+	Authors         []BookAuthor    `json:"authors,omitempty"`
+	Genres          []Genre         `json:"genres,omitempty"`
+	Tags            []string        `json:"tags,omitempty"`
+	CoverURL        string          `json:"cover_url,omitempty"`
+	DeletedAt       *time.Time      `json:"deleted_at,omitempty"`
 }
 
 type UpdateBookInput struct {
-	Title       *string    `json:"title"`
-	Author      *string    `json:"author"`
-	PublishDate *time.Time `json:"publish_date"`
-	Rating      *int       `json:"rating"`
+	Title       *string       `json:"title"`
+	Author      *string       `json:"author"`
+	PublishDate *time.Time    `json:"publish_date"`
+	Authors     *[]BookAuthor `json:"authors"`
+	ISBN10      *string       `json:"isbn10"`
+	ISBN13      *string       `json:"isbn13"`
+}
+
+// NormalizeISBN validates the ISBNs of the book and fills in both forms of
+// it. An ISBN-10 and ISBN-13 given together must refer to the same book.
+func (b *Book) NormalizeISBN() error {
+	isbn13, isbn10, err := normalizeISBNPair(b.ISBN10, b.ISBN13)
+	if err != nil {
+		return err
+	}
+
+	b.ISBN13, b.ISBN10 = isbn13, isbn10
+
+	return nil
+}
+
+// NormalizeISBN does the same as Book.NormalizeISBN for the fields being
+// updated. Setting either ISBN to an empty string clears both of them.
+func (i *UpdateBookInput) NormalizeISBN() error {
+	if i.ISBN10 == nil && i.ISBN13 == nil {
+		return nil
+	}
+
+	var isbn10, isbn13 string
+	if i.ISBN10 != nil {
+		isbn10 = *i.ISBN10
+	}
+
+	if i.ISBN13 != nil {
+		isbn13 = *i.ISBN13
+	}
+
+	isbn13, isbn10, err := normalizeISBNPair(isbn10, isbn13)
+	if err != nil {
+		return err
+	}
+
+	i.ISBN13, i.ISBN10 = &isbn13, &isbn10
+
+	return nil
+}
+
+func normalizeISBNPair(isbn10, isbn13 string) (string, string, error) {
+	if isbn10 == "" && isbn13 == "" {
+		return "", "", nil
+	}
+
+	var from10, from13 string
+	var err error
+
+	if isbn10 != "" {
+		if from10, _, err = NormalizeISBN(isbn10); err != nil {
+			return "", "", err
+		}
+	}
+
+	if isbn13 != "" {
+		if from13, _, err = NormalizeISBN(isbn13); err != nil {
+			return "", "", err
+		}
+	}
+
+	if from10 != "" && from13 != "" && from10 != from13 {
+		return "", "", ErrISBNMismatch
+	}
+
+	canonical := from13
+	if canonical == "" {
+		canonical = from10
+	}
+
+	return NormalizeISBN(canonical)
+}
+
+// PurgedBook is a book removed for good, along with what it referred to in
+// the blob store: its cover and the content hashes of its files.
+type PurgedBook struct {
+	ID           int64
+	CoverVersion string
+	FileHashes   []string
+}
+
+// BookFilter narrows down book listings. Zero values mean "no restriction".
+type BookFilter struct {
+	IDs             []int64
+	Title           string
+	Author          string
+	AuthorID        *int64
+	GenreID         *int64
+	Tags            []string
+	TagsMode        TagsMode
+	MinRating       *int
+	MaxRating       *int
+	PublishedAfter  *time.Time
+	PublishedBefore *time.Time
+}
+
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportJSON   ExportFormat = "json"
+)
+
+func (f ExportFormat) Valid() bool {
+	switch f {
+	case ExportCSV, ExportNDJSON, ExportJSON:
+		return true
+	}
+
+	return false
 }
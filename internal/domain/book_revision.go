@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// BookRevision is a full snapshot of a book taken after each change.
+type BookRevision struct {
+	BookID    int64     `json:"book_id"`
+	Revision  int       `json:"revision"`
+	Book      Book      `json:"book"`
+	AuthorID  int64     `json:"author_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BookFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type BookRevisionDiff struct {
+	BookID  int64             `json:"book_id"`
+	From    int               `json:"from"`
+	To      int               `json:"to"`
+	Changes []BookFieldChange `json:"changes"`
+}
+
+// DiffBooks returns the user-editable fields that differ between two books.
+func DiffBooks(from, to Book) []BookFieldChange {
+	changes := make([]BookFieldChange, 0)
+
+	if from.Title != to.Title {
+		changes = append(changes, BookFieldChange{Field: "title", From: from.Title, To: to.Title})
+	}
+
+	if from.Author != to.Author {
+		changes = append(changes, BookFieldChange{Field: "author", From: from.Author, To: to.Author})
+	}
+
+	if from.ISBN13 != to.ISBN13 {
+		changes = append(changes, BookFieldChange{Field: "isbn13", From: from.ISBN13, To: to.ISBN13})
+	}
+
+	if !from.PublishDate.Equal(to.PublishDate) {
+		changes = append(changes, BookFieldChange{Field: "publish_date", From: from.PublishDate, To: to.PublishDate})
+	}
+
+	if creditsKey(from.Authors) != creditsKey(to.Authors) {
+		changes = append(changes, BookFieldChange{Field: "authors", From: from.Authors, To: to.Authors})
+	}
+
+	return changes
+}
+
+// UpdateInput returns an input that brings a book to the state of b.
+func (b Book) UpdateInput() UpdateBookInput {
+	inp := UpdateBookInput{
+		Title:       &b.Title,
+		Author:      &b.Author,
+		PublishDate: &b.PublishDate,
+		ISBN10:      &b.ISBN10,
+		ISBN13:      &b.ISBN13,
+	}
+
+	// snapshots taken before author links existed only carry the free-text
+	// author, which is then resolved by name
+	if b.Authors != nil {
+		inp.Authors = &b.Authors
+	}
+
+	return inp
+}
+
+func creditsKey(authors []BookAuthor) string {
+	key := ""
+	for _, author := range authors {
+		key += fmt.Sprintf("%d:%s;", author.AuthorID, author.Role)
+	}
+
+	return key
+}
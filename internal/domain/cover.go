@@ -0,0 +1,30 @@
+package domain
+
+import "fmt"
+
+type CoverSize string
+
+const (
+	CoverSmall    CoverSize = "small"
+	CoverMedium   CoverSize = "medium"
+	CoverLarge    CoverSize = "large"
+	CoverOriginal CoverSize = "original"
+)
+
+// CoverWidths are the widths the thumbnails of a cover are generated at.
+var CoverWidths = map[CoverSize]int{
+	CoverSmall:  120,
+	CoverMedium: 300,
+	CoverLarge:  600,
+}
+
+func (s CoverSize) Valid() bool {
+	_, ok := CoverWidths[s]
+	return ok || s == CoverOriginal
+}
+
+// CoverPath is where a version of the cover of a book is served. Every upload
+// gets a new version, so responses can be cached forever.
+func CoverPath(bookID int64, version string) string {
+	return fmt.Sprintf("/covers/%d/%s", bookID, version)
+}
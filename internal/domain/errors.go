@@ -5,4 +5,38 @@ import "errors"
 var (
 	ErrBookNotFound        = errors.New("book not found")
 	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRevisionNotFound    = errors.New("book revision not found")
+	ErrAuthorNotFound      = errors.New("author not found")
+	ErrAuthorExists        = errors.New("author with such name already exists")
+	ErrAuthorHasBooks      = errors.New("author still has books")
+	ErrAuthorSelfMerge     = errors.New("can't merge an author into itself")
+	ErrInvalidAuthorRole   = errors.New("invalid author role")
+	ErrGenreNotFound       = errors.New("genre not found")
+	ErrGenreExists         = errors.New("genre with such slug already exists")
+	ErrGenreInUse          = errors.New("genre has books or subgenres")
+	ErrGenreCycle          = errors.New("genre can't be its own ancestor")
+	ErrEmptyGenreSlug      = errors.New("genre name has no letters or digits to make a slug of")
+	ErrTagNotFound         = errors.New("tag not found")
+	ErrInvalidISBN         = errors.New("invalid ISBN")
+	ErrISBNMismatch        = errors.New("ISBN-10 and ISBN-13 refer to different books")
+	ErrISBNConflict        = errors.New("book with such ISBN already exists")
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrOwnReviewVote       = errors.New("can't vote for own review")
+	ErrShelfNotFound       = errors.New("shelf not found")
+	ErrShelfExists         = errors.New("shelf with such name already exists")
+	ErrStatusShelf         = errors.New("reading status shelves can't be renamed or deleted")
+	ErrReservedShelfName   = errors.New("shelf name is reserved for a reading status shelf")
+	ErrShelfOrderMismatch  = errors.New("new order must list exactly the books on the shelf")
+	ErrSessionNotFound     = errors.New("reading session not found")
+	ErrCoverNotFound       = errors.New("cover not found")
+	ErrCoverTooLarge       = errors.New("cover image is too large")
+	ErrCoverType           = errors.New("cover must be a JPEG, PNG or WebP image")
+	ErrInvalidCover        = errors.New("cover image can't be decoded or has unsupported dimensions")
+	ErrFileNotFound        = errors.New("file not found")
+	ErrFileTooLarge        = errors.New("file is too large")
+	ErrFileType            = errors.New("file must be an EPUB or a PDF")
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrUnknownWebhookEvent = errors.New("unknown webhook event")
+	ErrInvalidRole         = errors.New("invalid role")
 )
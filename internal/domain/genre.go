@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Genre is a node of the curated genre hierarchy.
+type Genre struct {
+	ID       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	ParentID *int64  `json:"parent_id,omitempty"`
+	Children []Genre `json:"children,omitempty"`
+}
+
+type GenreInput struct {
+	Name     string `json:"name" validate:"required,gte=1,lte=255"`
+	Slug     string `json:"slug" validate:"omitempty,lte=255"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+func (i GenreInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type SetBookGenresInput struct {
+	GenreIDs []int64 `json:"genre_ids"`
+}
+
+type TagsInput struct {
+	Tags []string `json:"tags" validate:"required,min=1,dive,required,lte=64"`
+}
+
+func (i TagsInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type TagsMode string
+
+const (
+	TagsAny TagsMode = "any"
+	TagsAll TagsMode = "all"
+)
+
+type GenreFacet struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id,omitempty"`
+	Count    int    `json:"count"`
+}
+
+type TagFacet struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// BookFacets holds the number of books per genre and tag among the books
+// matching a filter.
+type BookFacets struct {
+	Genres []GenreFacet `json:"genres"`
+	Tags   []TagFacet   `json:"tags"`
+}
+
+// Slugify lowercases the letters and digits of name, in whatever script, and
+// joins the runs of them with dashes: "Science Fiction" becomes
+// "science-fiction" and "Научная фантастика" "научная-фантастика". Names
+// without any letter or digit give "".
+func Slugify(name string) string {
+	var (
+		b    strings.Builder
+		dash bool
+	)
+
+	for _, r := range strings.ToLower(name) {
+		// marks such as accents and vowel signs belong to the letter before
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !(unicode.IsMark(r) && b.Len() > 0) {
+			dash = true
+			continue
+		}
+
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+
+		dash = false
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// NormalizeTag makes "Sci Fi ", "sci  fi" and "SCI FI" the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
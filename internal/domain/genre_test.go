@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Science Fiction", "science-fiction"},
+		{"  Sci-Fi & Fantasy!  ", "sci-fi-fantasy"},
+		{"19th Century", "19th-century"},
+		{"Café Noir", "café-noir"},
+		{"Научная фантастика", "научная-фантастика"},
+		{"推理小説", "推理小説"},
+		{"हिन्दी साहित्य", "हिन्दी-साहित्य"},
+		{"???", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
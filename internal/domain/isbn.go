@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"strings"
+)
+
+// NormalizeISBN strips hyphens and spaces from an ISBN-10 or ISBN-13, checks
+// its checksum and returns it as ISBN-13 along with the ISBN-10 form, which is
+// empty for 979-prefixed numbers that have no ISBN-10 equivalent.
+func NormalizeISBN(isbn string) (isbn13, isbn10 string, err error) {
+	clean := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+
+	switch len(clean) {
+	case 10:
+		if !validISBN10(clean) {
+			return "", "", ErrInvalidISBN
+		}
+
+		return isbn10To13(clean), clean, nil
+	case 13:
+		if !validISBN13(clean) {
+			return "", "", ErrInvalidISBN
+		}
+
+		return clean, isbn13To10(clean), nil
+	}
+
+	return "", "", ErrInvalidISBN
+}
+
+func validISBN10(isbn string) bool {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var digit int
+
+		switch c := isbn[i]; {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case c == 'X' && i == 9:
+			digit = 10
+		default:
+			return false
+		}
+
+		sum += digit * (10 - i)
+	}
+
+	return sum%11 == 0
+}
+
+func validISBN13(isbn string) bool {
+	for i := 0; i < 13; i++ {
+		if isbn[i] < '0' || isbn[i] > '9' {
+			return false
+		}
+	}
+
+	return isbn13CheckDigit(isbn[:12]) == isbn[12]
+}
+
+func isbn13CheckDigit(first12 string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(first12[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+
+		sum += digit
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isbn10To13(isbn10 string) string {
+	first12 := "978" + isbn10[:9]
+	return first12 + string(isbn13CheckDigit(first12))
+}
+
+func isbn13To10(isbn13 string) string {
+	if !strings.HasPrefix(isbn13, "978") {
+		return ""
+	}
+
+	first9 := isbn13[3:12]
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(first9[i]-'0') * (10 - i)
+	}
+
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return first9 + "X"
+	}
+
+	return first9 + string(byte('0'+check))
+}
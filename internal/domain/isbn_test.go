@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		isbn   string
+		want13 string
+		want10 string
+	}{
+		{"0306406152", "9780306406157", "0306406152"},
+		{"9780306406157", "9780306406157", "0306406152"},
+		{"0-306-40615-2", "9780306406157", "0306406152"},
+		{"978 0 306 40615 7", "9780306406157", "0306406152"},
+		{"080442957X", "9780804429573", "080442957X"},
+		{"080442957x", "9780804429573", "080442957X"},
+		{"9780804429573", "9780804429573", "080442957X"},
+		{"043942089X", "9780439420891", "043942089X"},
+		{"9780439420891", "9780439420891", "043942089X"},
+		{"9791090636071", "9791090636071", ""},
+	}
+
+	for _, tt := range tests {
+		got13, got10, err := NormalizeISBN(tt.isbn)
+		if err != nil {
+			t.Errorf("NormalizeISBN(%q): %v", tt.isbn, err)
+			continue
+		}
+
+		if got13 != tt.want13 || got10 != tt.want10 {
+			t.Errorf("NormalizeISBN(%q) = %q, %q, want %q, %q", tt.isbn, got13, got10, tt.want13, tt.want10)
+		}
+	}
+}
+
+func TestNormalizeISBNInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"0306406153",
+		"9780306406158",
+		"X306406152",
+		"03064061X2",
+		"978030640615X",
+		"030640615",
+		"97803064061570",
+		"03O6406152",
+	}
+
+	for _, isbn := range tests {
+		if _, _, err := NormalizeISBN(isbn); !errors.Is(err, ErrInvalidISBN) {
+			t.Errorf("NormalizeISBN(%q) error = %v, want %v", isbn, err, ErrInvalidISBN)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrMFAChallenge          = errors.New("two-factor authentication code required")
+	ErrMFANotEnrolled        = errors.New("two-factor authentication is not set up")
+	ErrMFAAlreadyEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrInvalidMFACode        = errors.New("invalid two-factor authentication code")
+	ErrInvalidMFAToken       = errors.New("invalid or expired two-factor authentication token")
+	ErrMFAEnrollmentRequired = errors.New("two-factor authentication must be enabled for this role")
+)
+
+// MFAChallengeError is returned by sign-in instead of tokens when the user has
+// two-factor authentication enabled. Token is then exchanged, along with a
+// code, for the tokens. It matches ErrMFAChallenge.
+type MFAChallengeError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *MFAChallengeError) Error() string {
+	return ErrMFAChallenge.Error()
+}
+
+func (e *MFAChallengeError) Is(target error) bool {
+	return target == ErrMFAChallenge
+}
+
+// MFA is the TOTP setup of a user. It only protects sign-in once confirmed.
+// LastCounter is the period of the last code used, so that no code works
+// twice.
+type MFA struct {
+	UserID      int64
+	Secret      string
+	ConfirmedAt *time.Time
+	LastCounter int64
+}
+
+func (m MFA) Enabled() bool {
+	return m.ConfirmedAt != nil
+}
+
+// MFAEnrollment is shown to the user once, to be added to an authenticator
+// app, usually by scanning URI as a QR code.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// MFACodeInput carries a code from the authenticator app or, where stated, a
+// recovery code.
+type MFACodeInput struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+func (i MFACodeInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// MFAChallengeInput completes a sign-in with the token it returned and a code
+// from the authenticator app or a recovery code.
+type MFAChallengeInput struct {
+	Token string `json:"mfa_token" validate:"required"`
+	Code  string `json:"code" validate:"required,max=32"`
+}
+
+func (i MFAChallengeInput) Validate() error {
+	return validate.Struct(i)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ReadingSession is a stretch of reading of a book by a user. Progress is
+// recorded as pages read during the session, as the percentage of the book
+// reached at its end, or both.
+type ReadingSession struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	BookID     int64     `json:"book_id"`
+	Pages      *int      `json:"pages,omitempty"`
+	Percent    *float64  `json:"percent,omitempty"`
+	Finished   bool      `json:"finished"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type ReadingSessionInput struct {
+	Pages      *int      `json:"pages" validate:"omitempty,gte=0,lte=100000"`
+	Percent    *float64  `json:"percent" validate:"omitempty,gte=0,lte=100"`
+	Finished   bool      `json:"finished"`
+	StartedAt  time.Time `json:"started_at" validate:"required"`
+	FinishedAt time.Time `json:"finished_at" validate:"required"`
+}
+
+func (i ReadingSessionInput) Validate() error {
+	if err := validate.Struct(i); err != nil {
+		return err
+	}
+
+	if i.Pages == nil && i.Percent == nil {
+		return errors.New("either pages or percent is required")
+	}
+
+	if i.FinishedAt.Before(i.StartedAt) {
+		return errors.New("finished_at can't be before started_at")
+	}
+
+	return nil
+}
+
+// FinishesBook reports whether the session marks the book as read, either
+// explicitly or by reaching its last page.
+func (i ReadingSessionInput) FinishesBook() bool {
+	return i.Finished || (i.Percent != nil && *i.Percent >= 100)
+}
+
+type ReadingGoalInput struct {
+	Year  int `json:"year" validate:"required,gte=1900,lte=3000"`
+	Books int `json:"books" validate:"required,gt=0,lte=10000"`
+}
+
+func (i ReadingGoalInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type MonthCount struct {
+	Month string `json:"month"`
+	Books int    `json:"books"`
+}
+
+type DayPages struct {
+	Date  string `json:"date"`
+	Pages int    `json:"pages"`
+}
+
+type GoalProgress struct {
+	Year     int     `json:"year"`
+	Target   int     `json:"target"`
+	Finished int     `json:"finished"`
+	Percent  float64 `json:"percent"`
+}
+
+// ReadingStats summarises the reading of a user over a calendar year.
+type ReadingStats struct {
+	Year               int           `json:"year"`
+	BooksFinished      int           `json:"books_finished"`
+	FinishedPerMonth   []MonthCount  `json:"finished_per_month"`
+	PagesPerDay        []DayPages    `json:"pages_per_day"`
+	AveragePagesPerDay float64       `json:"average_pages_per_day"`
+	RatingsGiven       int           `json:"ratings_given"`
+	AverageRatingGiven float64       `json:"average_rating_given"`
+	Goal               *GoalProgress `json:"goal,omitempty"`
+}
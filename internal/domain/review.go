@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review is the rating a user gave to a book, optionally with some text. Each
+// user has at most one review per book.
+type Review struct {
+	ID           int64     `json:"id"`
+	BookID       int64     `json:"book_id"`
+	UserID       int64     `json:"user_id"`
+	Rating       int       `json:"rating"`
+	Text         string    `json:"text"`
+	HelpfulCount int       `json:"helpful_count"`
+	ReportCount  int       `json:"report_count,omitempty"`
+	Hidden       bool      `json:"hidden,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ReviewInput struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Text   string `json:"text" validate:"lte=10000"`
+}
+
+func (i ReviewInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ReportReviewInput struct {
+	Reason string `json:"reason" validate:"lte=1000"`
+}
+
+func (i ReportReviewInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ReviewSort string
+
+const (
+	ReviewsRecent  ReviewSort = "recent"
+	ReviewsHelpful ReviewSort = "helpful"
+)
+
+type ReviewFilter struct {
+	Sort          ReviewSort
+	IncludeHidden bool
+	Limit         int
+	Offset        int
+}
+
+// RatingHistogram holds the number of ratings per star, from 1 to 5.
+type RatingHistogram [MaxRating]int
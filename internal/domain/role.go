@@ -0,0 +1,18 @@
+package domain
+
+type Role string
+
+const (
+	RoleUser   Role = "user"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleEditor, RoleAdmin:
+		return true
+	}
+
+	return false
+}
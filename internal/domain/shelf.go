@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+type ShelfKind string
+
+const (
+	ShelfToRead  ShelfKind = "to_read"
+	ShelfReading ShelfKind = "reading"
+	ShelfRead    ShelfKind = "read"
+	ShelfCustom  ShelfKind = "custom"
+)
+
+// StatusShelves are created for every user. A book sits on at most one of
+// them at a time.
+var StatusShelves = []struct {
+	Kind ShelfKind
+	Name string
+}{
+	{ShelfToRead, "To read"},
+	{ShelfReading, "Reading"},
+	{ShelfRead, "Read"},
+}
+
+// IsStatusShelfName reports whether name is reserved for a reading status
+// shelf, ignoring case.
+func IsStatusShelfName(name string) bool {
+	for _, status := range StatusShelves {
+		if strings.EqualFold(strings.TrimSpace(name), status.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Shelf struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	Name      string       `json:"name"`
+	Kind      ShelfKind    `json:"kind"`
+	ShareSlug string       `json:"share_slug,omitempty"`
+	BookCount int          `json:"book_count"`
+	CreatedAt time.Time    `json:"created_at"`
+	Entries   []ShelfEntry `json:"entries,omitempty"`
+}
+
+type ShelfEntry struct {
+	BookID   int64     `json:"book_id"`
+	Position int       `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+	Book     *Book     `json:"book,omitempty"`
+}
+
+type ShelfInput struct {
+	Name string `json:"name" validate:"required,gte=1,lte=255"`
+}
+
+func (i ShelfInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ShelfBookInput struct {
+	BookID int64 `json:"book_id" validate:"required,gt=0"`
+}
+
+func (i ShelfBookInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ReorderShelfInput struct {
+	BookIDs []int64 `json:"book_ids" validate:"required"`
+}
+
+func (i ReorderShelfInput) Validate() error {
+	return validate.Struct(i)
+}
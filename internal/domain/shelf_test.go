@@ -0,0 +1,24 @@
+package domain
+
+import "testing"
+
+func TestIsStatusShelfName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Read", true},
+		{"read", true},
+		{" TO READ ", true},
+		{"Reading", true},
+		{"Read later", false},
+		{"To-read", false},
+		{"Favourites", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsStatusShelfName(tt.name); got != tt.want {
+			t.Errorf("IsStatusShelfName(%q) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
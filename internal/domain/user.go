@@ -2,6 +2,8 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -14,14 +16,163 @@ func init() {
 	validate = validator.New()
 }
 
-var ErrUserNotFound = errors.New("user with such credentials not found")
+var (
+	ErrUserNotFound             = errors.New("user with such credentials not found")
+	ErrAccountLocked            = errors.New("too many failed sign-in attempts")
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified     = errors.New("email address is already verified")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrVerificationThrottled    = errors.New("verification email was sent recently")
+	ErrInvalidResetToken        = errors.New("invalid or expired password reset token")
+	ErrEmailTaken               = errors.New("email address is already in use")
+	ErrWrongPassword            = errors.New("current password is wrong")
+	ErrUserDisabled             = errors.New("user is disabled")
+)
+
+// LockedError is returned by sign-in while the account or the caller's IP
+// address is locked out. It matches ErrAccountLocked.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, try again after %s", ErrAccountLocked, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// LoginFailures counts the failed sign-in attempts made for an account or
+// from an IP address, see LoginFailuresKey.
+type LoginFailures struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+// LoginFailuresKey identifies what failed sign-in attempts are counted
+// against: "email:" or "ip:" followed by the value.
+func LoginFailuresKey(kind, value string) string {
+	return kind + ":" + strings.ToLower(value)
+}
+
+// UnlockInput lifts the lockout of an account, an IP address or both.
+type UnlockInput struct {
+	Email string `json:"email" validate:"required_without=IP,omitempty,email"`
+	IP    string `json:"ip" validate:"required_without=Email,omitempty,ip"`
+}
+
+func (i UnlockInput) Validate() error {
+	return validate.Struct(i)
+}
 
 type User struct {
-	ID           int64     `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Password     string    `json:"password"`
-	RegisteredAt time.Time `json:"registered_at"`
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Password     string     `json:"-"`
+	Role         Role       `json:"role"`
+	RegisteredAt time.Time  `json:"registered_at"`
+	VerifiedAt   *time.Time `json:"verified_at,omitempty"`
+	// DeleteAt is set when the user asked for their account to be deleted,
+	// which happens then unless they sign in again before.
+	DeleteAt *time.Time `json:"delete_at,omitempty"`
+	// DisabledAt is set while an admin keeps the user from signing in.
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+// UserFilter searches users by name or email address. Zero values of Limit
+// and Offset are replaced by the service layer's defaults.
+type UserFilter struct {
+	Query  string
+	Role   Role
+	Limit  int
+	Offset int
+}
+
+// PurgedUser is an account removed for good, along with the content hashes
+// of the files it had uploaded.
+type PurgedUser struct {
+	ID         int64
+	FileHashes []string
+}
+
+type SetRoleInput struct {
+	Role Role `json:"role" validate:"required"`
+}
+
+func (i SetRoleInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// UpdateUserInput changes the profile of a user. A new email address has to
+// be verified again.
+type UpdateUserInput struct {
+	Name  *string `json:"name" validate:"omitempty,gte=2"`
+	Email *string `json:"email" validate:"omitempty,email"`
+}
+
+func (i UpdateUserInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,gte=6"`
+}
+
+func (i ChangePasswordInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// EmailVerification is a verification link sent to a user. The token in the
+// link is signed and carries Nonce, which can only be used once.
+type EmailVerification struct {
+	ID        int64
+	UserID    int64
+	Email     string
+	Nonce     string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// PasswordReset is a password reset link sent to a user. Only the SHA-256
+// hash of its token is stored.
+type PasswordReset struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (i ForgotPasswordInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,gte=6"`
+}
+
+func (i ResetPasswordInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (i ResendVerificationInput) Validate() error {
+	return validate.Struct(i)
 }
 
 type SignUpInput struct {
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	EventBookCreated  WebhookEvent = "book.created"
+	EventBookUpdated  WebhookEvent = "book.updated"
+	EventBookDeleted  WebhookEvent = "book.deleted"
+	EventBookRestored WebhookEvent = "book.restored"
+)
+
+var WebhookEvents = []WebhookEvent{EventBookCreated, EventBookUpdated, EventBookDeleted, EventBookRestored}
+
+func (e WebhookEvent) Valid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OutboxEvent is a change waiting to be fanned out to webhook subscriptions.
+// It is written in the same transaction as the change itself.
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	Type      WebhookEvent    `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type WebhookSubscription struct {
+	ID        int64          `json:"id"`
+	URL       string         `json:"url"`
+	Secret    string         `json:"secret,omitempty"`
+	Events    []WebhookEvent `json:"events"`
+	Active    bool           `json:"active"`
+	CreatedBy int64          `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type WebhookInput struct {
+	URL    string         `json:"url" validate:"required,url,lte=2048"`
+	Secret string         `json:"secret" validate:"omitempty,gte=16,lte=255"`
+	Events []WebhookEvent `json:"events" validate:"required,min=1"`
+	Active *bool          `json:"active"`
+}
+
+func (i WebhookInput) Validate() error {
+	if err := validate.Struct(i); err != nil {
+		return err
+	}
+
+	return validateWebhookTarget(i.URL, i.Events)
+}
+
+type UpdateWebhookInput struct {
+	URL    *string         `json:"url" validate:"omitempty,url,lte=2048"`
+	Events *[]WebhookEvent `json:"events" validate:"omitempty,min=1"`
+	Active *bool           `json:"active"`
+}
+
+func (i UpdateWebhookInput) Validate() error {
+	if err := validate.Struct(i); err != nil {
+		return err
+	}
+
+	var (
+		target string
+		events []WebhookEvent
+	)
+
+	if i.URL != nil {
+		target = *i.URL
+	}
+
+	if i.Events != nil {
+		events = *i.Events
+	}
+
+	return validateWebhookTarget(target, events)
+}
+
+func validateWebhookTarget(target string, events []WebhookEvent) error {
+	if target != "" {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.New("webhook url must be http or https")
+		}
+	}
+
+	for _, event := range events {
+		if !event.Valid() {
+			return ErrUnknownWebhookEvent
+		}
+	}
+
+	return nil
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to one subscription,
+// together with the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      WebhookEvent    `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	RedeliveryOf   *int64          `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	// Secret and URL of the subscription, loaded for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// BookEvent is a committed change of a book, as streamed to live clients.
+type BookEvent struct {
+	ID        uint64       `json:"id"`
+	Type      WebhookEvent `json:"type"`
+	Book      Book         `json:"book"`
+	CreatedAt time.Time    `json:"created_at"`
+}
@@ -0,0 +1,99 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type Audit struct {
+	db *sql.DB
+}
+
+func NewAudit(db *sql.DB) *Audit {
+	return &Audit{db}
+}
+
+func (r *Audit) Create(ctx context.Context, entry domain.AuditEntry) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO audit_log (actor_id, action, entity, entity_id, ip, user_agent, request_id, before, after, created_at) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		nullID(entry.ActorID), entry.Action, entry.Entity, nullID(entry.EntityID), entry.IP, entry.UserAgent, entry.RequestID,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.CreatedAt)
+
+	return err
+}
+
+func (r *Audit) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if filter.ActorID != nil {
+		conditions = append(conditions, fmt.Sprintf("actor_id=$%d", argId))
+		args = append(args, *filter.ActorID)
+		argId++
+	}
+
+	if filter.Entity != "" {
+		conditions = append(conditions, fmt.Sprintf("entity=$%d", argId))
+		args = append(args, filter.Entity)
+		argId++
+	}
+
+	if filter.Action != "" {
+		conditions = append(conditions, fmt.Sprintf("action=$%d", argId))
+		args = append(args, filter.Action)
+		argId++
+	}
+
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argId))
+		args = append(args, *filter.From)
+		argId++
+	}
+
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argId))
+		args = append(args, *filter.To)
+		argId++
+	}
+
+	query := "SELECT id, actor_id, action, entity, entity_id, ip, user_agent, request_id, before, after, created_at FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", argId, argId+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]domain.AuditEntry, 0)
+	for rows.Next() {
+		var (
+			entry    domain.AuditEntry
+			actorID  sql.NullInt64
+			entityID sql.NullInt64
+			// NULL can't be scanned into json.RawMessage
+			before, after []byte
+		)
+
+		if err := rows.Scan(&entry.ID, &actorID, &entry.Action, &entry.Entity, &entityID, &entry.IP, &entry.UserAgent,
+			&entry.RequestID, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		entry.ActorID = actorID.Int64
+		entry.EntityID = entityID.Int64
+		entry.Before = before
+		entry.After = after
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,239 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type Authors struct {
+	db *sql.DB
+}
+
+func NewAuthors(db *sql.DB) *Authors {
+	return &Authors{db}
+}
+
+func (r *Authors) Create(ctx context.Context, author domain.Author) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO authors (name, normalized_name, bio, created_at) values ($1, $2, $3, $4) RETURNING id",
+		author.Name, domain.NormalizeAuthorName(author.Name), author.Bio, author.CreatedAt).Scan(&id)
+	if isPgError(err, uniqueViolation) {
+		return 0, domain.ErrAuthorExists
+	}
+
+	return id, err
+}
+
+// FindOrCreate returns the ID of the author with the given name, creating one
+// if nobody with an equivalent name exists yet.
+func (r *Authors) FindOrCreate(ctx context.Context, name string) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO authors (name, normalized_name) values ($1, $2)
+		ON CONFLICT (normalized_name) DO UPDATE SET normalized_name=EXCLUDED.normalized_name
+		RETURNING id`, strings.Join(strings.Fields(name), " "), domain.NormalizeAuthorName(name)).Scan(&id)
+
+	return id, err
+}
+
+func (r *Authors) GetByID(ctx context.Context, id int64) (domain.Author, error) {
+	var author domain.Author
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id, name, bio, created_at FROM authors WHERE id=$1", id).
+		Scan(&author.ID, &author.Name, &author.Bio, &author.CreatedAt)
+	if err == sql.ErrNoRows {
+		return author, domain.ErrAuthorNotFound
+	}
+
+	return author, err
+}
+
+func (r *Authors) GetAll(ctx context.Context, name string) ([]domain.Author, error) {
+	query := "SELECT id, name, bio, created_at FROM authors"
+	args := make([]interface{}, 0)
+
+	if name != "" {
+		query += " WHERE name ILIKE $1"
+		args = append(args, "%"+name+"%")
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query+" ORDER BY name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := make([]domain.Author, 0)
+	for rows.Next() {
+		var author domain.Author
+		if err := rows.Scan(&author.ID, &author.Name, &author.Bio, &author.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		authors = append(authors, author)
+	}
+
+	return authors, rows.Err()
+}
+
+func (r *Authors) Update(ctx context.Context, id int64, inp domain.UpdateAuthorInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if inp.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=$%d, normalized_name=$%d", argId, argId+1))
+		args = append(args, *inp.Name, domain.NormalizeAuthorName(*inp.Name))
+		argId += 2
+	}
+
+	if inp.Bio != nil {
+		setValues = append(setValues, fmt.Sprintf("bio=$%d", argId))
+		args = append(args, *inp.Bio)
+		argId++
+	}
+
+	if len(setValues) == 0 {
+		_, err := r.GetByID(ctx, id)
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE authors SET %s WHERE id=$%d", strings.Join(setValues, ", "), argId)
+	args = append(args, id)
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return domain.ErrAuthorExists
+		}
+
+		return err
+	}
+
+	return authorAffected(res)
+}
+
+func (r *Authors) Delete(ctx context.Context, id int64) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM authors WHERE id=$1", id)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return domain.ErrAuthorHasBooks
+		}
+
+		return err
+	}
+
+	return authorAffected(res)
+}
+
+// Merge moves every book credit of the from authors to the target author and
+// deletes the from authors.
+func (r *Authors) Merge(ctx context.Context, target int64, from []int64) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO book_authors (book_id, author_id, role, position)
+		SELECT book_id, $1, role, position FROM book_authors WHERE author_id = ANY($2)
+		ON CONFLICT DO NOTHING`, target, pq.Array(from)); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_authors WHERE author_id = ANY($1)", pq.Array(from)); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM authors WHERE id = ANY($1)", pq.Array(from))
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != int64(len(from)) {
+		return domain.ErrAuthorNotFound
+	}
+
+	return tx.Commit()
+}
+
+// GetForBooks returns the contributors of each of the given books, keyed by
+// book ID, in one query.
+func (r *Authors) GetForBooks(ctx context.Context, bookIDs []int64) (map[int64][]domain.BookAuthor, error) {
+	result := make(map[int64][]domain.BookAuthor, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT ba.book_id, a.id, a.name, ba.role FROM book_authors ba
+		JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = ANY($1) ORDER BY ba.book_id, ba.position, a.name`, pq.Array(bookIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			bookID int64
+			author domain.BookAuthor
+		)
+
+		if err := rows.Scan(&bookID, &author.AuthorID, &author.Name, &author.Role); err != nil {
+			return nil, err
+		}
+
+		result[bookID] = append(result[bookID], author)
+	}
+
+	return result, rows.Err()
+}
+
+// SetForBook replaces the contributors of a book.
+func (r *Authors) SetForBook(ctx context.Context, bookID int64, authors []domain.BookAuthor) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_authors WHERE book_id=$1", bookID); err != nil {
+		return err
+	}
+
+	for i, author := range authors {
+		_, err := tx.ExecContext(ctx, "INSERT INTO book_authors (book_id, author_id, role, position) values ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+			bookID, author.AuthorID, author.Role, i)
+		if err != nil {
+			if isPgError(err, foreignKeyViolation) {
+				return domain.ErrAuthorNotFound
+			}
+
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func authorAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrAuthorNotFound
+	}
+
+	return nil
+}
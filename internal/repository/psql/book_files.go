@@ -0,0 +1,95 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/crud-app/internal/domain"
+)
+
+const bookFileColumns = "id, book_id, user_id, name, content_type, size, sha256, created_at"
+
+type BookFiles struct {
+	db *sql.DB
+}
+
+func NewBookFiles(db *sql.DB) *BookFiles {
+	return &BookFiles{db}
+}
+
+func (r *BookFiles) Create(ctx context.Context, file domain.BookFile) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO book_files (book_id, user_id, name, content_type, size, sha256, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		file.BookID, file.UserID, file.Name, file.ContentType, file.Size, file.SHA256, file.CreatedAt).Scan(&id)
+	if isPgError(err, foreignKeyViolation) {
+		return 0, domain.ErrBookNotFound
+	}
+
+	return id, err
+}
+
+// GetByID returns a file of the book owned by the user. Files of other users
+// are reported as not found.
+func (r *BookFiles) GetByID(ctx context.Context, bookID, userID, id int64) (domain.BookFile, error) {
+	file, err := scanBookFile(r.db.QueryRowContext(ctx, "SELECT "+bookFileColumns+` FROM book_files
+		WHERE id=$1 AND book_id=$2 AND user_id=$3`, id, bookID, userID))
+	if err == sql.ErrNoRows {
+		return file, domain.ErrFileNotFound
+	}
+
+	return file, err
+}
+
+func (r *BookFiles) List(ctx context.Context, bookID, userID int64) ([]domain.BookFile, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+bookFileColumns+` FROM book_files
+		WHERE book_id=$1 AND user_id=$2 ORDER BY created_at, id`, bookID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make([]domain.BookFile, 0)
+	for rows.Next() {
+		file, err := scanBookFile(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+func (r *BookFiles) Delete(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM book_files WHERE id=$1", id)
+	return err
+}
+
+// HashInUse reports whether any file still refers to the blob with the given
+// content hash.
+func (r *BookFiles) HashInUse(ctx context.Context, sha256 string) (bool, error) {
+	var inUse bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM book_files WHERE sha256=$1)", sha256).Scan(&inUse)
+
+	return inUse, err
+}
+
+// bookFileHashLock is the advisory lock class of content hashes.
+const bookFileHashLock = 37
+
+// LockHash serializes the decisions to store or delete the blob with the
+// given content hash until the transaction carried by ctx ends. It has no
+// effect outside of a transaction.
+func (r *BookFiles) LockHash(ctx context.Context, sha256 string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", bookFileHashLock, sha256)
+	return err
+}
+
+func scanBookFile(row rowScanner) (domain.BookFile, error) {
+	var file domain.BookFile
+	err := row.Scan(&file.ID, &file.BookID, &file.UserID, &file.Name, &file.ContentType, &file.Size, &file.SHA256, &file.CreatedAt)
+
+	return file, err
+}
@@ -0,0 +1,95 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type BookRevisions struct {
+	db *sql.DB
+}
+
+func NewBookRevisions(db *sql.DB) *BookRevisions {
+	return &BookRevisions{db}
+}
+
+// Create stores a snapshot of the book as its next revision and returns the
+// revision number. The book row stays locked until the transaction ends, so
+// that concurrent changes can't pick the same number.
+func (r *BookRevisions) Create(ctx context.Context, authorID int64, book domain.Book) (int, error) {
+	snapshot, err := json.Marshal(book)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT 1 FROM books WHERE id=$1 FOR UPDATE", book.ID); err != nil {
+		return 0, err
+	}
+
+	var revision int
+	err = tx.QueryRowContext(ctx, `INSERT INTO book_revisions (book_id, revision, snapshot, author_id)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3 FROM book_revisions WHERE book_id=$1
+		RETURNING revision`, book.ID, string(snapshot), nullID(authorID)).Scan(&revision)
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, tx.Commit()
+}
+
+func (r *BookRevisions) List(ctx context.Context, bookID int64) ([]domain.BookRevision, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT book_id, revision, snapshot, author_id, created_at FROM book_revisions WHERE book_id=$1 ORDER BY revision", bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]domain.BookRevision, 0)
+	for rows.Next() {
+		revision, err := scanBookRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *BookRevisions) Get(ctx context.Context, bookID int64, revision int) (domain.BookRevision, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, "SELECT book_id, revision, snapshot, author_id, created_at FROM book_revisions WHERE book_id=$1 AND revision=$2", bookID, revision)
+
+	rev, err := scanBookRevision(row)
+	if err == sql.ErrNoRows {
+		return rev, domain.ErrRevisionNotFound
+	}
+
+	return rev, err
+}
+
+func scanBookRevision(row rowScanner) (domain.BookRevision, error) {
+	var (
+		rev      domain.BookRevision
+		snapshot []byte
+		authorID sql.NullInt64
+	)
+
+	if err := row.Scan(&rev.BookID, &rev.Revision, &snapshot, &authorID, &rev.CreatedAt); err != nil {
+		return rev, err
+	}
+
+	rev.AuthorID = authorID.Int64
+	err := json.Unmarshal(snapshot, &rev.Book)
+
+	return rev, err
+}
@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
 )
 
 type Books struct {
@@ -17,17 +20,23 @@ func NewBooks(db *sql.DB) *Books {
 	return &Books{db}
 }
 
-func (b *Books) CreateBook(ctx context.Context, book domain.Book) error {
-	_, err := b.db.Exec("INSERT INTO books (title, author, publish_date, rating) values ($1, $2, $3, $4)",
-		book.Title, book.Author, book.PublishDate, book.Rating)
+const bookColumns = "id, title, author, COALESCE(isbn10, ''), COALESCE(isbn13, ''), publish_date, " +
+	"CASE WHEN ratings_count > 0 THEN ratings_sum::float8 / ratings_count ELSE 0 END, ratings_count, ratings_histogram, " +
+	"COALESCE(cover_version, '')"
+
+func (b *Books) CreateBook(ctx context.Context, book domain.Book) (int64, error) {
+	var id int64
+	err := conn(ctx, b.db).QueryRowContext(ctx, "INSERT INTO books (title, author, isbn10, isbn13, publish_date) values ($1, $2, $3, $4, $5) RETURNING id",
+		book.Title, book.Author, nullString(book.ISBN10), nullString(book.ISBN13), book.PublishDate).Scan(&id)
+	if isPgError(err, uniqueViolation) {
+		return 0, domain.ErrISBNConflict
+	}
 
-	return err
+	return id, err
 }
 
 func (b *Books) GetByID(ctx context.Context, id int64) (domain.Book, error) {
-	var book domain.Book
-	err := b.db.QueryRow("SELECT id, title, author, publish_date, rating FROM books WHERE id=$1", id).
-		Scan(&book.ID, &book.Title, &book.Author, &book.PublishDate, &book.Rating)
+	book, err := scanBook(conn(ctx, b.db).QueryRowContext(ctx, "SELECT "+bookColumns+" FROM books WHERE id=$1 AND deleted_at IS NULL", id))
 	if err == sql.ErrNoRows {
 		return book, domain.ErrBookNotFound
 	}
@@ -35,29 +44,325 @@ func (b *Books) GetByID(ctx context.Context, id int64) (domain.Book, error) {
 	return book, err
 }
 
-func (b *Books) GetAll(ctx context.Context) ([]domain.Book, error) {
-	rows, err := b.db.Query("SELECT id, title, author, publish_date, rating FROM books")
+func (b *Books) GetByISBN(ctx context.Context, isbn13 string) (domain.Book, error) {
+	book, err := scanBook(conn(ctx, b.db).QueryRowContext(ctx, "SELECT "+bookColumns+" FROM books WHERE isbn13=$1 AND deleted_at IS NULL", isbn13))
+	if err == sql.ErrNoRows {
+		return book, domain.ErrBookNotFound
+	}
+
+	return book, err
+}
+
+func scanBook(row rowScanner, extra ...interface{}) (domain.Book, error) {
+	var (
+		book         domain.Book
+		histogram    []int64
+		coverVersion string
+	)
+
+	dest := append([]interface{}{&book.ID, &book.Title, &book.Author, &book.ISBN10, &book.ISBN13, &book.PublishDate,
+		&book.Rating, &book.RatingCount, pq.Array(&histogram), &coverVersion}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return book, err
+	}
+
+	if coverVersion != "" {
+		book.CoverURL = domain.CoverPath(book.ID, coverVersion)
+	}
+
+	for i := 0; i < len(histogram) && i < len(book.RatingHistogram); i++ {
+		book.RatingHistogram[i] = int(histogram[i])
+	}
+
+	return book, nil
+}
+
+func (b *Books) GetAll(ctx context.Context, filter domain.BookFilter) ([]domain.Book, error) {
+	books := make([]domain.Book, 0)
+	err := b.Stream(ctx, filter, func(book domain.Book) error {
+		books = append(books, book)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// Stream walks over the books matching filter one row at a time and passes each
+// of them to fn, so callers never have to hold the whole result set in memory.
+func (b *Books) Stream(ctx context.Context, filter domain.BookFilter, fn func(domain.Book) error) error {
+	where, args := booksFilterQuery(filter, "deleted_at IS NULL")
+
+	rows, err := conn(ctx, b.db).QueryContext(ctx, "SELECT "+bookColumns+" FROM books"+where+" ORDER BY id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(book); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func booksFilterQuery(filter domain.BookFilter, conditions ...string) (string, []interface{}) {
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if filter.IDs != nil {
+		conditions = append(conditions, fmt.Sprintf("id = ANY($%d)", argId))
+		args = append(args, pq.Array(filter.IDs))
+		argId++
+	}
+
+	if filter.Title != "" {
+		conditions = append(conditions, fmt.Sprintf("title ILIKE $%d", argId))
+		args = append(args, "%"+filter.Title+"%")
+		argId++
+	}
+
+	if filter.Author != "" {
+		conditions = append(conditions, fmt.Sprintf("author ILIKE $%d", argId))
+		args = append(args, "%"+filter.Author+"%")
+		argId++
+	}
+
+	if filter.AuthorID != nil {
+		conditions = append(conditions, fmt.Sprintf("id IN (SELECT book_id FROM book_authors WHERE author_id=$%d)", argId))
+		args = append(args, *filter.AuthorID)
+		argId++
+	}
+
+	if filter.GenreID != nil {
+		conditions = append(conditions, fmt.Sprintf(`id IN (SELECT book_id FROM book_genres WHERE genre_id IN (
+			WITH RECURSIVE sub AS (
+				SELECT id FROM genres WHERE id=$%d
+				UNION ALL
+				SELECT g.id FROM genres g JOIN sub ON g.parent_id = sub.id
+			)
+			SELECT id FROM sub))`, argId))
+		args = append(args, *filter.GenreID)
+		argId++
+	}
+
+	if len(filter.Tags) > 0 {
+		tagsQuery := fmt.Sprintf("id IN (SELECT bt.book_id FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name = ANY($%d)", argId)
+		args = append(args, pq.Array(filter.Tags))
+		argId++
+
+		if filter.TagsMode == domain.TagsAll {
+			tagsQuery += fmt.Sprintf(" GROUP BY bt.book_id HAVING count(DISTINCT t.name) = $%d", argId)
+			args = append(args, len(filter.Tags))
+			argId++
+		}
+
+		conditions = append(conditions, tagsQuery+")")
+	}
+
+	if filter.MinRating != nil {
+		conditions = append(conditions, fmt.Sprintf("ratings_count > 0 AND ratings_sum >= $%d * ratings_count", argId))
+		args = append(args, *filter.MinRating)
+		argId++
+	}
+
+	if filter.MaxRating != nil {
+		conditions = append(conditions, fmt.Sprintf("ratings_sum <= $%d * ratings_count", argId))
+		args = append(args, *filter.MaxRating)
+		argId++
+	}
+
+	if filter.PublishedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("publish_date >= $%d", argId))
+		args = append(args, *filter.PublishedAfter)
+		argId++
+	}
+
+	if filter.PublishedBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("publish_date <= $%d", argId))
+		args = append(args, *filter.PublishedBefore)
+		argId++
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Delete moves the book to the trash. It is removed for good by Purge once the
+// retention period is over.
+func (b *Books) Delete(ctx context.Context, id int64) error {
+	res, err := conn(ctx, b.db).ExecContext(ctx, "UPDATE books SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+
+	return bookAffected(res)
+}
+
+// Facets counts the books matching filter per genre and per tag.
+func (b *Books) Facets(ctx context.Context, filter domain.BookFilter) (domain.BookFacets, error) {
+	facets := domain.BookFacets{
+		Genres: make([]domain.GenreFacet, 0),
+		Tags:   make([]domain.TagFacet, 0),
+	}
+
+	where, args := booksFilterQuery(filter, "deleted_at IS NULL")
+	matching := "SELECT id FROM books" + where
+
+	rows, err := conn(ctx, b.db).QueryContext(ctx, `SELECT g.id, g.name, g.parent_id, count(*) FROM book_genres bg
+		JOIN genres g ON g.id = bg.genre_id
+		WHERE bg.book_id IN (`+matching+`)
+		GROUP BY g.id, g.name, g.parent_id ORDER BY count(*) DESC, g.name`, args...)
+	if err != nil {
+		return facets, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet domain.GenreFacet
+		if err := rows.Scan(&facet.ID, &facet.Name, &facet.ParentID, &facet.Count); err != nil {
+			return facets, err
+		}
+
+		facets.Genres = append(facets.Genres, facet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return facets, err
+	}
+
+	tagRows, err := conn(ctx, b.db).QueryContext(ctx, `SELECT t.name, count(*) FROM book_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE bt.book_id IN (`+matching+`)
+		GROUP BY t.name ORDER BY count(*) DESC, t.name`, args...)
+	if err != nil {
+		return facets, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var facet domain.TagFacet
+		if err := tagRows.Scan(&facet.Name, &facet.Count); err != nil {
+			return facets, err
+		}
+
+		facets.Tags = append(facets.Tags, facet)
+	}
+
+	return facets, tagRows.Err()
+}
+
+func (b *Books) GetTrash(ctx context.Context) ([]domain.Book, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, "SELECT "+bookColumns+", deleted_at FROM books WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	books := make([]domain.Book, 0)
 	for rows.Next() {
-		var book domain.Book
-		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.PublishDate, &book.Rating); err != nil {
+		var deletedAt time.Time
+		book, err := scanBook(rows, &deletedAt)
+		if err != nil {
 			return nil, err
 		}
 
+		book.DeletedAt = &deletedAt
+
 		books = append(books, book)
 	}
 
 	return books, rows.Err()
 }
 
-func (b *Books) Delete(ctx context.Context, id int64) error {
-	_, err := b.db.Exec("DELETE FROM books WHERE id=$1", id)
+func (b *Books) Restore(ctx context.Context, id int64) error {
+	res, err := conn(ctx, b.db).ExecContext(ctx, "UPDATE books SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return domain.ErrISBNConflict
+		}
+
+		return err
+	}
 
-	return err
+	return bookAffected(res)
+}
+
+// Purge hard-deletes books that were trashed before the given moment and
+// returns them. Their files go along, and the books report the hashes of
+// those, read before the cascade.
+func (b *Books) Purge(ctx context.Context, before time.Time) ([]domain.PurgedBook, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, `WITH purged AS (
+			DELETE FROM books WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING id, cover_version
+		)
+		SELECT p.id, COALESCE(p.cover_version, ''), f.sha256 FROM purged p
+		LEFT JOIN book_files f ON f.book_id = p.id
+		ORDER BY p.id`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := make([]domain.PurgedBook, 0)
+	for rows.Next() {
+		var (
+			book domain.PurgedBook
+			hash sql.NullString
+		)
+
+		if err := rows.Scan(&book.ID, &book.CoverVersion, &hash); err != nil {
+			return nil, err
+		}
+
+		if n := len(books); n == 0 || books[n-1].ID != book.ID {
+			books = append(books, book)
+		}
+
+		if hash.Valid {
+			last := &books[len(books)-1]
+			last.FileHashes = append(last.FileHashes, hash.String)
+		}
+	}
+
+	return books, rows.Err()
+}
+
+// SetCover points the book to a new version of its cover, or to none if
+// version is empty, and returns the version it replaced.
+func (b *Books) SetCover(ctx context.Context, id int64, version string) (string, error) {
+	tx, err := beginTx(ctx, b.db)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(cover_version, '') FROM books WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return "", domain.ErrBookNotFound
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE books SET cover_version=$1 WHERE id=$2", nullString(version), id); err != nil {
+		return "", err
+	}
+
+	return previous, tx.Commit()
 }
 
 func (b *Books) Update(ctx context.Context, id int64, inp domain.UpdateBookInput) error {
@@ -79,21 +384,52 @@ func (b *Books) Update(ctx context.Context, id int64, inp domain.UpdateBookInput
 
 	if inp.PublishDate != nil {
 		setValues = append(setValues, fmt.Sprintf("publish_date=$%d", argId))
-		args = append(args, *inp.Author)
+		args = append(args, *inp.PublishDate)
+		argId++
+	}
+
+	if inp.ISBN10 != nil {
+		setValues = append(setValues, fmt.Sprintf("isbn10=$%d", argId))
+		args = append(args, nullString(*inp.ISBN10))
 		argId++
 	}
 
-	if inp.Rating != nil {
-		setValues = append(setValues, fmt.Sprintf("rating=$%d", argId))
-		args = append(args, *inp.Rating)
+	if inp.ISBN13 != nil {
+		setValues = append(setValues, fmt.Sprintf("isbn13=$%d", argId))
+		args = append(args, nullString(*inp.ISBN13))
 		argId++
 	}
 
+	if len(setValues) == 0 {
+		return nil
+	}
+
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf("UPDATE books SET %s WHERE id=%d", setQuery, argId+1)
+	query := fmt.Sprintf("UPDATE books SET %s WHERE id=$%d AND deleted_at IS NULL", setQuery, argId)
 	args = append(args, id)
 
-	_, err := b.db.Exec(query, args...)
-	return err
-}
\ No newline at end of file
+	res, err := conn(ctx, b.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return domain.ErrISBNConflict
+		}
+
+		return err
+	}
+
+	return bookAffected(res)
+}
+
+func bookAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrBookNotFound
+	}
+
+	return nil
+}
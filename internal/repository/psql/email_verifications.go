@@ -0,0 +1,48 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type EmailVerifications struct {
+	db *sql.DB
+}
+
+func NewEmailVerifications(db *sql.DB) *EmailVerifications {
+	return &EmailVerifications{db}
+}
+
+func (r *EmailVerifications) Create(ctx context.Context, v domain.EmailVerification) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO email_verifications (user_id, email, nonce, expires_at, created_at) values ($1, $2, $3, $4, $5)",
+		v.UserID, v.Email, v.Nonce, v.ExpiresAt, v.CreatedAt)
+
+	return err
+}
+
+// LastSentAt returns when the latest verification was sent to the user, or
+// the zero time if none was.
+func (r *EmailVerifications) LastSentAt(ctx context.Context, userID int64) (time.Time, error) {
+	var at sql.NullTime
+	err := r.db.QueryRowContext(ctx, "SELECT max(created_at) FROM email_verifications WHERE user_id=$1", userID).Scan(&at)
+
+	return at.Time, err
+}
+
+// Use marks the verification as used and returns it. A verification that is
+// unknown, expired or already used yields ErrInvalidVerificationToken.
+func (r *EmailVerifications) Use(ctx context.Context, nonce string, at time.Time) (domain.EmailVerification, error) {
+	v := domain.EmailVerification{Nonce: nonce, UsedAt: &at}
+	err := r.db.QueryRowContext(ctx, `UPDATE email_verifications SET used_at=$2
+		WHERE nonce=$1 AND used_at IS NULL AND expires_at > $2
+		RETURNING id, user_id, email, expires_at, created_at`, nonce, at).
+		Scan(&v.ID, &v.UserID, &v.Email, &v.ExpiresAt, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return v, domain.ErrInvalidVerificationToken
+	}
+
+	return v, err
+}
@@ -0,0 +1,17 @@
+package psql
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+func isPgError(err error, code pq.ErrorCode) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
@@ -0,0 +1,176 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type Genres struct {
+	db *sql.DB
+}
+
+func NewGenres(db *sql.DB) *Genres {
+	return &Genres{db}
+}
+
+func (r *Genres) Create(ctx context.Context, genre domain.Genre) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO genres (name, slug, parent_id) values ($1, $2, $3) RETURNING id",
+		genre.Name, genre.Slug, genre.ParentID).Scan(&id)
+
+	switch {
+	case isPgError(err, uniqueViolation):
+		return 0, domain.ErrGenreExists
+	case isPgError(err, foreignKeyViolation):
+		return 0, domain.ErrGenreNotFound
+	}
+
+	return id, err
+}
+
+func (r *Genres) GetByID(ctx context.Context, id int64) (domain.Genre, error) {
+	var genre domain.Genre
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id, name, slug, parent_id FROM genres WHERE id=$1", id).
+		Scan(&genre.ID, &genre.Name, &genre.Slug, &genre.ParentID)
+	if err == sql.ErrNoRows {
+		return genre, domain.ErrGenreNotFound
+	}
+
+	return genre, err
+}
+
+func (r *Genres) GetAll(ctx context.Context) ([]domain.Genre, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT id, name, slug, parent_id FROM genres ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := make([]domain.Genre, 0)
+	for rows.Next() {
+		var genre domain.Genre
+		if err := rows.Scan(&genre.ID, &genre.Name, &genre.Slug, &genre.ParentID); err != nil {
+			return nil, err
+		}
+
+		genres = append(genres, genre)
+	}
+
+	return genres, rows.Err()
+}
+
+func (r *Genres) Update(ctx context.Context, genre domain.Genre) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE genres SET name=$1, slug=$2, parent_id=$3 WHERE id=$4",
+		genre.Name, genre.Slug, genre.ParentID, genre.ID)
+	if err != nil {
+		switch {
+		case isPgError(err, uniqueViolation):
+			return domain.ErrGenreExists
+		case isPgError(err, foreignKeyViolation):
+			return domain.ErrGenreNotFound
+		}
+
+		return err
+	}
+
+	return genreAffected(res)
+}
+
+func (r *Genres) Delete(ctx context.Context, id int64) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM genres WHERE id=$1", id)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return domain.ErrGenreInUse
+		}
+
+		return err
+	}
+
+	return genreAffected(res)
+}
+
+// IsAncestor reports whether ancestor is id itself or one of its parents.
+func (r *Genres) IsAncestor(ctx context.Context, ancestor, id int64) (bool, error) {
+	var found bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `WITH RECURSIVE up AS (
+			SELECT id, parent_id FROM genres WHERE id=$1
+			UNION ALL
+			SELECT g.id, g.parent_id FROM genres g JOIN up ON g.id = up.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM up WHERE id=$2)`, id, ancestor).Scan(&found)
+
+	return found, err
+}
+
+func (r *Genres) GetForBooks(ctx context.Context, bookIDs []int64) (map[int64][]domain.Genre, error) {
+	result := make(map[int64][]domain.Genre, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT bg.book_id, g.id, g.name, g.slug, g.parent_id FROM book_genres bg
+		JOIN genres g ON g.id = bg.genre_id
+		WHERE bg.book_id = ANY($1) ORDER BY bg.book_id, g.name`, pq.Array(bookIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			bookID int64
+			genre  domain.Genre
+		)
+
+		if err := rows.Scan(&bookID, &genre.ID, &genre.Name, &genre.Slug, &genre.ParentID); err != nil {
+			return nil, err
+		}
+
+		result[bookID] = append(result[bookID], genre)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *Genres) SetForBook(ctx context.Context, bookID int64, genreIDs []int64) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_genres WHERE book_id=$1", bookID); err != nil {
+		return err
+	}
+
+	if len(genreIDs) > 0 {
+		_, err := tx.ExecContext(ctx, `INSERT INTO book_genres (book_id, genre_id)
+			SELECT $1, unnest($2::bigint[]) ON CONFLICT DO NOTHING`, bookID, pq.Array(genreIDs))
+		if err != nil {
+			if isPgError(err, foreignKeyViolation) {
+				return domain.ErrGenreNotFound
+			}
+
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func genreAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrGenreNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package psql
+
+import (
+	"database/sql"
+)
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
@@ -0,0 +1,69 @@
+package psql
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testDB connects to the database given by TEST_DATABASE_URL, as a
+// postgres:// URL, and applies the given migrations in a schema of its own
+// that is dropped when the test ends. Tests using it are skipped when the
+// variable isn't set.
+func testDB(t *testing.T, migrations ...string) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := "test_" + hex.EncodeToString(b)
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, name := range migrations {
+		up, err := os.ReadFile(filepath.Join("..", "..", "..", "migrations", name+".up.sql"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.Exec(string(up)); err != nil {
+			t.Fatalf("migration %s: %s", name, err)
+		}
+	}
+
+	return db
+}
@@ -0,0 +1,74 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type LoginFailures struct {
+	db *sql.DB
+}
+
+func NewLoginFailures(db *sql.DB) *LoginFailures {
+	return &LoginFailures{db}
+}
+
+// Get returns the failures counted against the given keys. Keys without any
+// are left out.
+func (r *LoginFailures) Get(ctx context.Context, keys []string) ([]domain.LoginFailures, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT key, failures, last_failed_at, locked_until FROM login_failures WHERE key = ANY($1)",
+		pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []domain.LoginFailures
+	for rows.Next() {
+		var f domain.LoginFailures
+		if err := rows.Scan(&f.Key, &f.Failures, &f.LastFailedAt, &f.LockedUntil); err != nil {
+			return nil, err
+		}
+
+		failures = append(failures, f)
+	}
+
+	return failures, rows.Err()
+}
+
+// Add counts a failure against key. The count starts over if the previous
+// failure is older than window.
+func (r *LoginFailures) Add(ctx context.Context, key string, at time.Time, window time.Duration) (domain.LoginFailures, error) {
+	f := domain.LoginFailures{Key: key}
+	err := r.db.QueryRowContext(ctx, `INSERT INTO login_failures (key, failures, last_failed_at) values ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_failures.last_failed_at < $3 THEN 1 ELSE login_failures.failures + 1 END,
+			last_failed_at = EXCLUDED.last_failed_at
+		RETURNING failures, last_failed_at, locked_until`, key, at, at.Add(-window)).
+		Scan(&f.Failures, &f.LastFailedAt, &f.LockedUntil)
+
+	return f, err
+}
+
+func (r *LoginFailures) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE login_failures SET locked_until=$2 WHERE key=$1", key, until)
+
+	return err
+}
+
+// Clear forgets the failures counted against key, which also lifts its lock.
+func (r *LoginFailures) Clear(ctx context.Context, key string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM login_failures WHERE key=$1", key)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
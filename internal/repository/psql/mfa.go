@@ -0,0 +1,124 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type MFA struct {
+	db *sql.DB
+}
+
+func NewMFA(db *sql.DB) *MFA {
+	return &MFA{db}
+}
+
+func (r *MFA) Get(ctx context.Context, userID int64) (domain.MFA, error) {
+	m := domain.MFA{UserID: userID}
+	err := r.db.QueryRowContext(ctx, "SELECT secret, confirmed_at, last_counter FROM user_mfa WHERE user_id=$1", userID).
+		Scan(&m.Secret, &m.ConfirmedAt, &m.LastCounter)
+	if err == sql.ErrNoRows {
+		return m, domain.ErrMFANotEnrolled
+	}
+
+	return m, err
+}
+
+// Enroll stores a new unconfirmed secret for the user, replacing an earlier
+// unconfirmed one. It fails with ErrMFAAlreadyEnabled if the user has a
+// confirmed one.
+func (r *MFA) Enroll(ctx context.Context, userID int64, secret string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO user_mfa (user_id, secret, created_at) values ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret=EXCLUDED.secret, last_counter=0, created_at=EXCLUDED.created_at
+		WHERE user_mfa.confirmed_at IS NULL`, userID, secret, at)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// Confirm enables the pending setup of the user, recording the counter of the
+// code it was confirmed with, and replaces the recovery codes.
+func (r *MFA) Confirm(ctx context.Context, userID, counter int64, codeHashes []string, at time.Time) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE user_mfa SET confirmed_at=$2, last_counter=$3 WHERE user_id=$1 AND confirmed_at IS NULL",
+		userID, at, counter)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO mfa_recovery_codes (user_id, code_hash) values ($1, $2)", userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseCounter records that the code of the given period was used. It returns
+// false if that code, or a later one, was used already.
+func (r *MFA) UseCounter(ctx context.Context, userID, counter int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE user_mfa SET last_counter=$2 WHERE user_id=$1 AND last_counter < $2", userID, counter)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+// UseRecoveryCode marks the recovery code as used. It returns false if the
+// user has no such unused code.
+func (r *MFA) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, at time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE mfa_recovery_codes SET used_at=$3 WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL",
+		userID, codeHash, at)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+// Delete removes the setup of the user along with their recovery codes.
+func (r *MFA) Delete(ctx context.Context, userID int64) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
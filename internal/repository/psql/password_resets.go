@@ -0,0 +1,64 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type PasswordResets struct {
+	db *sql.DB
+}
+
+func NewPasswordResets(db *sql.DB) *PasswordResets {
+	return &PasswordResets{db}
+}
+
+func (r *PasswordResets) Create(ctx context.Context, reset domain.PasswordReset) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO password_resets (user_id, token_hash, expires_at, created_at) values ($1, $2, $3, $4)",
+		reset.UserID, reset.TokenHash, reset.ExpiresAt, reset.CreatedAt)
+
+	return err
+}
+
+// LastSentAt returns when the latest reset was sent to the user, or the zero
+// time if none was.
+func (r *PasswordResets) LastSentAt(ctx context.Context, userID int64) (time.Time, error) {
+	var at sql.NullTime
+	err := r.db.QueryRowContext(ctx, "SELECT max(created_at) FROM password_resets WHERE user_id=$1", userID).Scan(&at)
+
+	return at.Time, err
+}
+
+// Use marks the reset as used and returns it, along with every other reset
+// still pending for the same user. A reset that is unknown, expired or
+// already used yields ErrInvalidResetToken.
+func (r *PasswordResets) Use(ctx context.Context, tokenHash string, at time.Time) (domain.PasswordReset, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return domain.PasswordReset{}, err
+	}
+	defer tx.Rollback()
+
+	reset := domain.PasswordReset{TokenHash: tokenHash, UsedAt: &at}
+	err = tx.QueryRowContext(ctx, `UPDATE password_resets SET used_at=$2
+		WHERE token_hash=$1 AND used_at IS NULL AND expires_at > $2
+		RETURNING id, user_id, expires_at, created_at`, tokenHash, at).
+		Scan(&reset.ID, &reset.UserID, &reset.ExpiresAt, &reset.CreatedAt)
+	if err == sql.ErrNoRows {
+		return reset, domain.ErrInvalidResetToken
+	}
+
+	if err != nil {
+		return reset, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE password_resets SET used_at=$2 WHERE user_id=$1 AND used_at IS NULL",
+		reset.UserID, at); err != nil {
+		return reset, err
+	}
+
+	return reset, tx.Commit()
+}
@@ -0,0 +1,63 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"time"
+
+	"github.com/crud-app/pkg/ratelimit"
+)
+
+// rateLimitsSweepRatio is the share of calls to Take that also drop the
+// buckets that have filled up.
+const rateLimitsSweepRatio = 0.01
+
+// RateLimits keeps token buckets in the database, so that the limits hold
+// across all the instances of the service.
+type RateLimits struct {
+	db *sql.DB
+}
+
+func NewRateLimits(db *sql.DB) *RateLimits {
+	return &RateLimits{db}
+}
+
+// Take runs in its own transaction, so that a request being rate limited
+// never holds a bucket locked for longer than the update itself.
+func (r *RateLimits) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	// a new bucket starts out full, which is what a zero State stands for
+	if _, err := tx.ExecContext(ctx, `INSERT INTO rate_limits (key, tokens, updated_at, full_at) values ($1, $2, $3, $3)
+		ON CONFLICT (key) DO NOTHING`, key, float64(limit.Burst), now); err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	var state ratelimit.State
+	if err := tx.QueryRowContext(ctx, "SELECT tokens, updated_at FROM rate_limits WHERE key=$1 FOR UPDATE", key).
+		Scan(&state.Tokens, &state.UpdatedAt); err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	state, res := ratelimit.Take(state, limit, now)
+
+	if _, err := tx.ExecContext(ctx, "UPDATE rate_limits SET tokens=$2, updated_at=$3, full_at=$4 WHERE key=$1",
+		key, state.Tokens, state.UpdatedAt, now.Add(res.Reset)); err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	if rand.Float64() < rateLimitsSweepRatio {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM rate_limits WHERE full_at < $1 AND key <> $2", now, key); err != nil {
+			return ratelimit.Result{}, err
+		}
+	}
+
+	return res, tx.Commit()
+}
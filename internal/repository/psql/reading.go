@@ -0,0 +1,170 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+const readingSessionColumns = "id, user_id, book_id, pages, percent, finished, started_at, finished_at"
+
+type Reading struct {
+	db *sql.DB
+}
+
+func NewReading(db *sql.DB) *Reading {
+	return &Reading{db}
+}
+
+func (r *Reading) CreateSession(ctx context.Context, session domain.ReadingSession) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `INSERT INTO reading_sessions (user_id, book_id, pages, percent, finished, started_at, finished_at)
+		values ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		session.UserID, session.BookID, session.Pages, session.Percent, session.Finished, session.StartedAt, session.FinishedAt).Scan(&id)
+	if isPgError(err, foreignKeyViolation) {
+		return 0, domain.ErrBookNotFound
+	}
+
+	return id, err
+}
+
+func (r *Reading) GetSessions(ctx context.Context, userID, bookID int64) ([]domain.ReadingSession, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+readingSessionColumns+` FROM reading_sessions
+		WHERE user_id=$1 AND book_id=$2 ORDER BY started_at`, userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]domain.ReadingSession, 0)
+	for rows.Next() {
+		var (
+			session domain.ReadingSession
+			pages   sql.NullInt64
+			percent sql.NullFloat64
+		)
+
+		if err := rows.Scan(&session.ID, &session.UserID, &session.BookID, &pages, &percent,
+			&session.Finished, &session.StartedAt, &session.FinishedAt); err != nil {
+			return nil, err
+		}
+
+		if pages.Valid {
+			n := int(pages.Int64)
+			session.Pages = &n
+		}
+
+		if percent.Valid {
+			session.Percent = &percent.Float64
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *Reading) DeleteSession(ctx context.Context, userID, bookID, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM reading_sessions WHERE id=$1 AND user_id=$2 AND book_id=$3", id, userID, bookID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// FinishedPerMonth counts the books the user finished in [from, to), by month.
+// A book finished twice in the same month counts once.
+func (r *Reading) FinishedPerMonth(ctx context.Context, userID int64, from, to time.Time) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT to_char(finished_at, 'YYYY-MM') AS month, count(DISTINCT book_id)
+		FROM reading_sessions WHERE user_id=$1 AND finished AND finished_at >= $2 AND finished_at < $3
+		GROUP BY month`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			month string
+			count int
+		)
+
+		if err := rows.Scan(&month, &count); err != nil {
+			return nil, err
+		}
+
+		counts[month] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// PagesPerDay sums the pages read in [from, to) by the day the sessions ended.
+// Days without any pages recorded are omitted.
+func (r *Reading) PagesPerDay(ctx context.Context, userID int64, from, to time.Time) ([]domain.DayPages, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT to_char(finished_at, 'YYYY-MM-DD') AS day, sum(pages)
+		FROM reading_sessions WHERE user_id=$1 AND pages > 0 AND finished_at >= $2 AND finished_at < $3
+		GROUP BY day ORDER BY day`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]domain.DayPages, 0)
+	for rows.Next() {
+		var day domain.DayPages
+		if err := rows.Scan(&day.Date, &day.Pages); err != nil {
+			return nil, err
+		}
+
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+// RatingsGiven returns the number and the average of the ratings the user
+// gave in [from, to).
+func (r *Reading) RatingsGiven(ctx context.Context, userID int64, from, to time.Time) (int, float64, error) {
+	var (
+		count int
+		avg   float64
+	)
+
+	err := r.db.QueryRowContext(ctx, `SELECT count(*), COALESCE(avg(rating), 0) FROM reviews
+		WHERE user_id=$1 AND updated_at >= $2 AND updated_at < $3`, userID, from, to).Scan(&count, &avg)
+
+	return count, avg, err
+}
+
+func (r *Reading) SetGoal(ctx context.Context, userID int64, year, books int) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO reading_goals (user_id, year, books) values ($1, $2, $3)
+		ON CONFLICT (user_id, year) DO UPDATE SET books=EXCLUDED.books`, userID, year, books)
+
+	return err
+}
+
+// GetGoal returns the number of books the user aims to read in the year, or 0
+// if they didn't set a goal.
+func (r *Reading) GetGoal(ctx context.Context, userID int64, year int) (int, error) {
+	var books int
+	err := r.db.QueryRowContext(ctx, "SELECT books FROM reading_goals WHERE user_id=$1 AND year=$2", userID, year).Scan(&books)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return books, err
+}
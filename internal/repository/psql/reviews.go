@@ -0,0 +1,323 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/crud-app/internal/domain"
+)
+
+const reviewColumns = "id, book_id, user_id, rating, text, helpful_count, report_count, hidden, created_at, updated_at"
+
+type Reviews struct {
+	db *sql.DB
+}
+
+func NewReviews(db *sql.DB) *Reviews {
+	return &Reviews{db}
+}
+
+// Upsert creates or replaces the review of a user for a book and adjusts the
+// rating stats of the book by the difference, in a single transaction that
+// holds the lock of the book row.
+func (r *Reviews) Upsert(ctx context.Context, review domain.Review) (domain.Review, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return review, err
+	}
+	defer tx.Rollback()
+
+	// a missing review can't be locked, so two first reviews of the same user
+	// would both count; the book row serializes them instead
+	err = lockBook(ctx, tx, review.BookID)
+	if err == sql.ErrNoRows {
+		return review, domain.ErrBookNotFound
+	}
+
+	if err != nil {
+		return review, err
+	}
+
+	var (
+		oldRating int
+		oldHidden bool
+	)
+
+	err = tx.QueryRowContext(ctx, "SELECT rating, hidden FROM reviews WHERE book_id=$1 AND user_id=$2 FOR UPDATE",
+		review.BookID, review.UserID).Scan(&oldRating, &oldHidden)
+
+	switch {
+	case err == sql.ErrNoRows:
+		// first review of the user for this book
+	case err != nil:
+		return review, err
+	case !oldHidden:
+		if err := adjustRatingStats(ctx, tx, review.BookID, oldRating, -1); err != nil {
+			return review, err
+		}
+	}
+
+	row := tx.QueryRowContext(ctx, `INSERT INTO reviews (book_id, user_id, rating, text, created_at, updated_at) values ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (book_id, user_id) DO UPDATE SET rating=EXCLUDED.rating, text=EXCLUDED.text, updated_at=EXCLUDED.updated_at
+		RETURNING `+reviewColumns, review.BookID, review.UserID, review.Rating, review.Text, review.UpdatedAt)
+
+	saved, err := scanReview(row)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return review, domain.ErrBookNotFound
+		}
+
+		return review, err
+	}
+
+	if !saved.Hidden {
+		if err := adjustRatingStats(ctx, tx, saved.BookID, saved.Rating, 1); err != nil {
+			return review, err
+		}
+	}
+
+	return saved, tx.Commit()
+}
+
+func (r *Reviews) Delete(ctx context.Context, bookID, userID int64) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockBook(ctx, tx, bookID)
+	if err == sql.ErrNoRows {
+		return domain.ErrReviewNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		rating int
+		hidden bool
+	)
+
+	err = tx.QueryRowContext(ctx, "DELETE FROM reviews WHERE book_id=$1 AND user_id=$2 RETURNING rating, hidden", bookID, userID).
+		Scan(&rating, &hidden)
+	if err == sql.ErrNoRows {
+		return domain.ErrReviewNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !hidden {
+		if err := adjustRatingStats(ctx, tx, bookID, rating, -1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Reviews) GetByID(ctx context.Context, bookID, id int64) (domain.Review, error) {
+	review, err := scanReview(r.db.QueryRowContext(ctx, "SELECT "+reviewColumns+" FROM reviews WHERE id=$1 AND book_id=$2", id, bookID))
+	if err == sql.ErrNoRows {
+		return review, domain.ErrReviewNotFound
+	}
+
+	return review, err
+}
+
+func (r *Reviews) GetByUser(ctx context.Context, bookID, userID int64) (domain.Review, error) {
+	review, err := scanReview(r.db.QueryRowContext(ctx, "SELECT "+reviewColumns+" FROM reviews WHERE book_id=$1 AND user_id=$2", bookID, userID))
+	if err == sql.ErrNoRows {
+		return review, domain.ErrReviewNotFound
+	}
+
+	return review, err
+}
+
+func (r *Reviews) List(ctx context.Context, bookID int64, filter domain.ReviewFilter) ([]domain.Review, error) {
+	query := "SELECT " + reviewColumns + " FROM reviews WHERE book_id=$1"
+	if !filter.IncludeHidden {
+		query += " AND NOT hidden"
+	}
+
+	switch filter.Sort {
+	case domain.ReviewsHelpful:
+		query += " ORDER BY helpful_count DESC, id DESC"
+	default:
+		query += " ORDER BY created_at DESC, id DESC"
+	}
+
+	rows, err := r.db.QueryContext(ctx, query+" LIMIT $2 OFFSET $3", bookID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]domain.Review, 0)
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// Vote marks a review as helpful for the user. Voting twice has no effect.
+func (r *Reviews) Vote(ctx context.Context, reviewID, userID int64) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO review_votes (review_id, user_id) values ($1, $2) ON CONFLICT DO NOTHING", reviewID, userID)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return domain.ErrReviewNotFound
+		}
+
+		return err
+	}
+
+	if err := adjustReviewCounter(ctx, tx, res, "helpful_count", reviewID, 1); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Reviews) Unvote(ctx context.Context, reviewID, userID int64) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM review_votes WHERE review_id=$1 AND user_id=$2", reviewID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := adjustReviewCounter(ctx, tx, res, "helpful_count", reviewID, -1); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Report flags a review for moderation. A user can report a review only once.
+func (r *Reviews) Report(ctx context.Context, reviewID, userID int64, reason string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO review_reports (review_id, user_id, reason) values ($1, $2, $3) ON CONFLICT DO NOTHING",
+		reviewID, userID, reason)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return domain.ErrReviewNotFound
+		}
+
+		return err
+	}
+
+	if err := adjustReviewCounter(ctx, tx, res, "report_count", reviewID, 1); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetHidden hides a review from listings and removes its rating from the
+// book stats, or reverts that.
+func (r *Reviews) SetHidden(ctx context.Context, bookID, reviewID int64, hidden bool) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockBook(ctx, tx, bookID)
+	if err == sql.ErrNoRows {
+		return domain.ErrReviewNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var rating int
+	err = tx.QueryRowContext(ctx, "UPDATE reviews SET hidden=$1 WHERE id=$2 AND book_id=$3 AND hidden<>$1 RETURNING rating",
+		hidden, reviewID, bookID).Scan(&rating)
+	if err == sql.ErrNoRows {
+		// either there is no such review or it already is in the requested state
+		if _, err := r.GetByID(ctx, bookID, reviewID); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	sign := 1
+	if hidden {
+		sign = -1
+	}
+
+	if err := adjustRatingStats(ctx, tx, bookID, rating, sign); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockBook locks the row of the book for the rest of the transaction. Every
+// change to the rating stats takes it before touching a review, so that they
+// all lock in the same order.
+func lockBook(ctx context.Context, tx querier, bookID int64) error {
+	var id int64
+	return tx.QueryRowContext(ctx, "SELECT id FROM books WHERE id=$1 FOR UPDATE", bookID).Scan(&id)
+}
+
+// adjustRatingStats adds (sign=1) or removes (sign=-1) a single rating from the
+// aggregated stats of the book.
+func adjustRatingStats(ctx context.Context, tx querier, bookID int64, rating, sign int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE books SET ratings_count = ratings_count + $2, ratings_sum = ratings_sum + $3,
+		ratings_histogram[$4] = ratings_histogram[$4] + $2 WHERE id=$1`, bookID, sign, sign*rating, rating)
+
+	return err
+}
+
+// adjustReviewCounter changes a counter column of the review when the
+// statement behind res did affect a row.
+func adjustReviewCounter(ctx context.Context, tx querier, res sql.Result, column string, reviewID int64, delta int) error {
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("UPDATE reviews SET %[1]s = %[1]s + $1 WHERE id=$2", column), delta, reviewID)
+
+	return err
+}
+
+func scanReview(row rowScanner) (domain.Review, error) {
+	var review domain.Review
+	err := row.Scan(&review.ID, &review.BookID, &review.UserID, &review.Rating, &review.Text, &review.HelpfulCount,
+		&review.ReportCount, &review.Hidden, &review.CreatedAt, &review.UpdatedAt)
+
+	return review, err
+}
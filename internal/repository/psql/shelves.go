@@ -0,0 +1,221 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// shelfColumns counts only the books that are not in the trash.
+const shelfColumns = `s.id, s.user_id, s.name, s.kind, COALESCE(s.share_slug, ''), s.created_at,
+	(SELECT count(*) FROM shelf_entries e JOIN books b ON b.id = e.book_id AND b.deleted_at IS NULL WHERE e.shelf_id = s.id)`
+
+type Shelves struct {
+	db *sql.DB
+}
+
+func NewShelves(db *sql.DB) *Shelves {
+	return &Shelves{db}
+}
+
+// EnsureStatusShelves creates the reading status shelves the user doesn't
+// have yet. Only an existing status shelf counts as a conflict: a custom shelf
+// taking the name makes it fail rather than leave the user without the
+// status shelf.
+func (r *Shelves) EnsureStatusShelves(ctx context.Context, userID int64) error {
+	for _, status := range domain.StatusShelves {
+		_, err := r.db.ExecContext(ctx, `INSERT INTO shelves (user_id, name, kind) values ($1, $2, $3)
+			ON CONFLICT (user_id, kind) WHERE kind <> 'custom' DO NOTHING`, userID, status.Name, status.Kind)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Shelves) Create(ctx context.Context, shelf domain.Shelf) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, "INSERT INTO shelves (user_id, name, kind, created_at) values ($1, $2, $3, $4) RETURNING id",
+		shelf.UserID, shelf.Name, shelf.Kind, shelf.CreatedAt).Scan(&id)
+	if isPgError(err, uniqueViolation) {
+		return 0, domain.ErrShelfExists
+	}
+
+	return id, err
+}
+
+func (r *Shelves) GetByID(ctx context.Context, id int64) (domain.Shelf, error) {
+	shelf, err := scanShelf(r.db.QueryRowContext(ctx, "SELECT "+shelfColumns+" FROM shelves s WHERE s.id=$1", id))
+	if err == sql.ErrNoRows {
+		return shelf, domain.ErrShelfNotFound
+	}
+
+	return shelf, err
+}
+
+func (r *Shelves) GetBySlug(ctx context.Context, slug string) (domain.Shelf, error) {
+	shelf, err := scanShelf(r.db.QueryRowContext(ctx, "SELECT "+shelfColumns+" FROM shelves s WHERE s.share_slug=$1", slug))
+	if err == sql.ErrNoRows {
+		return shelf, domain.ErrShelfNotFound
+	}
+
+	return shelf, err
+}
+
+func (r *Shelves) GetByUser(ctx context.Context, userID int64) ([]domain.Shelf, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+shelfColumns+` FROM shelves s WHERE s.user_id=$1
+		ORDER BY s.kind = 'custom', s.created_at, s.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shelves := make([]domain.Shelf, 0)
+	for rows.Next() {
+		shelf, err := scanShelf(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		shelves = append(shelves, shelf)
+	}
+
+	return shelves, rows.Err()
+}
+
+func (r *Shelves) Rename(ctx context.Context, id int64, name string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE shelves SET name=$1 WHERE id=$2", name, id)
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return domain.ErrShelfExists
+		}
+
+		return err
+	}
+
+	return shelfAffected(res)
+}
+
+func (r *Shelves) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM shelves WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	return shelfAffected(res)
+}
+
+// SetShareSlug publishes the shelf under slug, or unpublishes it when slug is
+// empty.
+func (r *Shelves) SetShareSlug(ctx context.Context, id int64, slug string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE shelves SET share_slug=$1 WHERE id=$2", nullString(slug), id)
+	if err != nil {
+		return err
+	}
+
+	return shelfAffected(res)
+}
+
+func (r *Shelves) GetEntries(ctx context.Context, shelfID int64) ([]domain.ShelfEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT e.book_id, e.position, e.added_at FROM shelf_entries e
+		JOIN books b ON b.id = e.book_id AND b.deleted_at IS NULL
+		WHERE e.shelf_id=$1 ORDER BY e.position, e.added_at`, shelfID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]domain.ShelfEntry, 0)
+	for rows.Next() {
+		var entry domain.ShelfEntry
+		if err := rows.Scan(&entry.BookID, &entry.Position, &entry.AddedAt); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// AddBook puts the book at the end of the shelf. When the shelf is a reading
+// status shelf, the book is taken off the user's other status shelves.
+func (r *Shelves) AddBook(ctx context.Context, shelf domain.Shelf, bookID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if shelf.Kind != domain.ShelfCustom {
+		_, err := tx.ExecContext(ctx, `DELETE FROM shelf_entries WHERE book_id=$1 AND shelf_id IN (
+			SELECT id FROM shelves WHERE user_id=$2 AND kind <> 'custom' AND id <> $3)`, bookID, shelf.UserID, shelf.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO shelf_entries (shelf_id, book_id, position)
+		SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM shelf_entries WHERE shelf_id=$1
+		ON CONFLICT DO NOTHING`, shelf.ID, bookID)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return domain.ErrBookNotFound
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Shelves) RemoveBook(ctx context.Context, shelfID, bookID int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM shelf_entries WHERE shelf_id=$1 AND book_id=$2", shelfID, bookID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrBookNotFound
+	}
+
+	return nil
+}
+
+// Reorder renumbers the entries of the shelf following the order of bookIDs.
+func (r *Shelves) Reorder(ctx context.Context, shelfID int64, bookIDs []int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE shelf_entries e SET position = o.position
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS o(book_id, position)
+		WHERE e.shelf_id=$1 AND e.book_id = o.book_id`, shelfID, pq.Array(bookIDs))
+
+	return err
+}
+
+func scanShelf(row rowScanner) (domain.Shelf, error) {
+	var shelf domain.Shelf
+	err := row.Scan(&shelf.ID, &shelf.UserID, &shelf.Name, &shelf.Kind, &shelf.ShareSlug, &shelf.CreatedAt, &shelf.BookCount)
+
+	return shelf, err
+}
+
+func shelfAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrShelfNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type Tags struct {
+	db *sql.DB
+}
+
+func NewTags(db *sql.DB) *Tags {
+	return &Tags{db}
+}
+
+// Add attaches the already normalized tags to a book, creating the tags that
+// don't exist yet.
+func (r *Tags) Add(ctx context.Context, bookID, userID int64, tags []string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT DO NOTHING", pq.Array(tags)); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO book_tags (book_id, tag_id, added_by)
+		SELECT $1, id, $2 FROM tags WHERE name = ANY($3)
+		ON CONFLICT DO NOTHING`, bookID, nullID(userID), pq.Array(tags))
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return domain.ErrBookNotFound
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Tags) Remove(ctx context.Context, bookID int64, tag string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM book_tags WHERE book_id=$1 AND tag_id=(SELECT id FROM tags WHERE name=$2)", bookID, tag)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrTagNotFound
+	}
+
+	return nil
+}
+
+func (r *Tags) GetForBooks(ctx context.Context, bookIDs []int64) (map[int64][]string, error) {
+	result := make(map[int64][]string, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT bt.book_id, t.name FROM book_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE bt.book_id = ANY($1) ORDER BY bt.book_id, t.name`, pq.Array(bookIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			bookID int64
+			tag    string
+		)
+
+		if err := rows.Scan(&bookID, &tag); err != nil {
+			return nil, err
+		}
+
+		result[bookID] = append(result[bookID], tag)
+	}
+
+	return result, rows.Err()
+}
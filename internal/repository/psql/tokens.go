@@ -33,3 +33,16 @@ func (r *Tokens) Get(ctx context.Context, token string) (domain.RefreshSession,
 
 	return t, err
 }
+
+// DeleteByUser revokes all the refresh sessions of the user and returns how
+// many there were.
+func (r *Tokens) DeleteByUser(ctx context.Context, userID int64) (int, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id=$1", userID)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
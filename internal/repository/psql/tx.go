@@ -0,0 +1,91 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+)
+
+type txKey struct{}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Transactor runs a function in a database transaction. Repositories given the
+// context passed to the function take part in that transaction.
+type Transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{db}
+}
+
+// WithinTransaction commits the transaction if fn succeeds and rolls it back
+// otherwise. Nested calls join the outer transaction.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// conn returns the transaction carried by ctx, if any, or db.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// txn is a transaction that may belong to an outer Transactor, in which case
+// committing and rolling back are left to it.
+type txn struct {
+	*sql.Tx
+	owned bool
+}
+
+// beginTx starts a transaction, or joins the one carried by ctx.
+func beginTx(ctx context.Context, db *sql.DB) (txn, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return txn{Tx: tx}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return txn{}, err
+	}
+
+	return txn{Tx: tx, owned: true}, nil
+}
+
+func (t txn) Commit() error {
+	if !t.owned {
+		return nil
+	}
+
+	return t.Tx.Commit()
+}
+
+func (t txn) Rollback() error {
+	if !t.owned {
+		return nil
+	}
+
+	return t.Tx.Rollback()
+}
@@ -3,9 +3,17 @@ package psql
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
 )
 
+const userColumns = "id, name, email, role, registered_at, verified_at, delete_at, disabled_at"
+
 type Users struct {
 	db *sql.DB
 }
@@ -14,17 +22,405 @@ func NewUsers(db *sql.DB) *Users {
 	return &Users{db}
 }
 
-func (r *Users) Create(ctx context.Context, user domain.User) error {
-	_, err := r.db.Exec("INSERT INTO users (name, email, password, registered_at) values ($1, $2, $3, $4)",
-		user.Name, user.Email, user.Password, user.RegisteredAt)
+func (r *Users) Create(ctx context.Context, user domain.User) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `INSERT INTO users (name, email, password, role, registered_at, verified_at)
+		values ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		user.Name, user.Email, user.Password, user.Role, user.RegisteredAt, user.VerifiedAt).Scan(&id)
 
-	return err
+	return id, err
 }
 
 func (r *Users) GetByCredentials(ctx context.Context, email, password string) (domain.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email=$1 AND password=$2",
+		email, password))
+	if err == sql.ErrNoRows {
+		return user, domain.ErrUserNotFound
+	}
+
+	return user, err
+}
+
+func (r *Users) GetRole(ctx context.Context, id int64) (domain.Role, error) {
+	var role domain.Role
+	err := r.db.QueryRowContext(ctx, "SELECT role FROM users WHERE id=$1", id).Scan(&role)
+	if err == sql.ErrNoRows {
+		return role, domain.ErrUserNotFound
+	}
+
+	return role, err
+}
+
+func (r *Users) SetRole(ctx context.Context, id int64, role domain.Role) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET role=$1 WHERE id=$2", role, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *Users) GetByID(ctx context.Context, id int64) (domain.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id=$1", id))
+	if err == sql.ErrNoRows {
+		return user, domain.ErrUserNotFound
+	}
+
+	return user, err
+}
+
+func (r *Users) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email=$1", email))
+	if err == sql.ErrNoRows {
+		return user, domain.ErrUserNotFound
+	}
+
+	return user, err
+}
+
+// Update changes the profile of the user. Changing the email address clears
+// verified_at, and fails with ErrEmailTaken if another user has it.
+func (r *Users) Update(ctx context.Context, id int64, inp domain.UpdateUserInput) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if inp.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=$%d", argId))
+		args = append(args, *inp.Name)
+		argId++
+	}
+
+	if inp.Email != nil {
+		var taken bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE email=$1 AND id<>$2)", *inp.Email, id).
+			Scan(&taken); err != nil {
+			return err
+		}
+
+		if taken {
+			return domain.ErrEmailTaken
+		}
+
+		setValues = append(setValues, fmt.Sprintf("email=$%d, verified_at=CASE WHEN email=$%d THEN verified_at END", argId, argId))
+		args = append(args, *inp.Email)
+		argId++
+	}
+
+	if len(setValues) == 0 {
+		_, err := r.GetByID(ctx, id)
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id=$%d", strings.Join(setValues, ", "), argId)
+	args = append(args, id)
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return tx.Commit()
+}
+
+// Search looks the filter's query up in the names and email addresses of the
+// users, oldest users first.
+func (r *Users) Search(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if filter.Query != "" {
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR email ILIKE $%d)", argId, argId))
+		args = append(args, "%"+filter.Query+"%")
+		argId++
+	}
+
+	if filter.Role != "" {
+		conditions = append(conditions, fmt.Sprintf("role=$%d", argId))
+		args = append(args, filter.Role)
+		argId++
+	}
+
+	query := "SELECT " + userColumns + " FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", argId, argId+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]domain.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// SetDisabledAt disables the user, or enables them again if at is nil.
+func (r *Users) SetDisabledAt(ctx context.Context, id int64, at *time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET disabled_at=$1 WHERE id=$2", at, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetDeleteAt schedules the deletion of the user, or cancels it if at is nil.
+func (r *Users) SetDeleteAt(ctx context.Context, id int64, at *time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET delete_at=$1 WHERE id=$2", at, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Purge deletes the users whose deletion was due before the given time,
+// along with their sessions, pending emails, 2FA setup, shelves, reading
+// history, reviews, votes, reports and uploaded files. The rating stats and
+// review counters they counted in are adjusted. It returns the users with the
+// hashes of their files, whose content is left to the caller.
+func (r *Users) Purge(ctx context.Context, before time.Time) ([]domain.PurgedUser, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM users WHERE delete_at < $1 RETURNING id", before)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := purgeReviews(ctx, tx, ids); err != nil {
+		return nil, err
+	}
+
+	hashes, err := purgeFiles(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range []string{"refresh_tokens", "email_verifications", "password_resets", "shelves", "reading_sessions",
+		"reading_goals", "user_mfa", "mfa_recovery_codes"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = ANY($1)", pq.Array(ids)); err != nil {
+			return nil, err
+		}
+	}
+
+	users := make([]domain.PurgedUser, len(ids))
+	for i, id := range ids {
+		users[i] = domain.PurgedUser{ID: id, FileHashes: hashes[id]}
+	}
+
+	return users, tx.Commit()
+}
+
+// purgeReviews deletes the reviews, votes and reports of the users. The books
+// reviewed are locked first, in the same order as the other changes to their
+// rating stats.
+func purgeReviews(ctx context.Context, tx querier, userIDs []int64) error {
+	_, err := tx.ExecContext(ctx, `SELECT id FROM books WHERE id IN (SELECT book_id FROM reviews WHERE user_id = ANY($1))
+		ORDER BY id FOR UPDATE`, pq.Array(userIDs))
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM reviews WHERE user_id = ANY($1) RETURNING book_id, rating, hidden",
+		pq.Array(userIDs))
+	if err != nil {
+		return err
+	}
+
+	type deletedReview struct {
+		bookID int64
+		rating int
+	}
+
+	var counted []deletedReview
+	for rows.Next() {
+		var (
+			review deletedReview
+			hidden bool
+		)
+
+		if err := rows.Scan(&review.bookID, &review.rating, &hidden); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if !hidden {
+			counted = append(counted, review)
+		}
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, review := range counted {
+		if err := adjustRatingStats(ctx, tx, review.bookID, review.rating, -1); err != nil {
+			return err
+		}
+	}
+
+	// votes and reports on the users' own reviews went with them
+	for _, counter := range []struct{ table, column string }{
+		{"review_votes", "helpful_count"},
+		{"review_reports", "report_count"},
+	} {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE reviews r SET %[2]s = r.%[2]s - t.n
+			FROM (SELECT review_id, count(*) AS n FROM %[1]s WHERE user_id = ANY($1) GROUP BY review_id) t
+			WHERE r.id = t.review_id`, counter.table, counter.column), pq.Array(userIDs))
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+counter.table+" WHERE user_id = ANY($1)", pq.Array(userIDs)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// purgeFiles deletes the file records of the users and returns their content
+// hashes by user.
+func purgeFiles(ctx context.Context, tx querier, userIDs []int64) (map[int64][]string, error) {
+	rows, err := tx.QueryContext(ctx, "DELETE FROM book_files WHERE user_id = ANY($1) RETURNING user_id, sha256",
+		pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[int64][]string)
+	for rows.Next() {
+		var (
+			userID int64
+			hash   string
+		)
+
+		if err := rows.Scan(&userID, &hash); err != nil {
+			return nil, err
+		}
+
+		hashes[userID] = append(hashes[userID], hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+// SetVerified marks the email address of the user as verified, provided it is
+// still the given one.
+func (r *Users) SetVerified(ctx context.Context, id int64, email string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET verified_at=$3 WHERE id=$1 AND email=$2", id, email, at)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	return nil
+}
+
+func (r *Users) SetPassword(ctx context.Context, id int64, password string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET password=$1 WHERE id=$2", password, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetByIDs loads the users with the given IDs. IDs that don't exist are
+// skipped, so the result may be shorter than ids.
+func (r *Users) GetByIDs(ctx context.Context, ids []int64) ([]domain.User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]domain.User, 0, len(ids))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func scanUser(row rowScanner) (domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow("SELECT id, name, email, registered_at FROM users WHERE email=$1 AND password=$2", email, password).
-		Scan(&user.ID, &user.Name, &user.Email, &user.RegisteredAt)
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.RegisteredAt, &user.VerifiedAt, &user.DeleteAt,
+		&user.DisabledAt)
 
 	return user, err
-}
\ No newline at end of file
+}
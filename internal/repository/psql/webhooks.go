@@ -0,0 +1,371 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+const (
+	webhookColumns  = "id, url, secret, events, active, COALESCE(created_by, 0), created_at"
+	deliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+	COALESCE(d.last_status_code, 0), COALESCE(d.last_error, ''), d.redelivery_of, d.created_at, d.delivered_at`
+)
+
+// inactiveSubscriptionError is recorded on the deliveries given up on when
+// their subscription is deactivated.
+const inactiveSubscriptionError = "subscription inactive"
+
+type Webhooks struct {
+	db *sql.DB
+}
+
+func NewWebhooks(db *sql.DB) *Webhooks {
+	return &Webhooks{db}
+}
+
+// Enqueue adds an event to the outbox. Called with the context of a
+// Transactor, the event is only published if the surrounding change commits.
+func (r *Webhooks) Enqueue(ctx context.Context, event domain.OutboxEvent) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "INSERT INTO outbox_events (type, data, created_at) values ($1, $2, $3)",
+		event.Type, string(event.Data), event.CreatedAt)
+
+	return err
+}
+
+// FanOut takes up to limit undispatched events from the outbox and queues a
+// delivery of each of them to every active subscription interested in it. It
+// returns the number of events dispatched.
+func (r *Webhooks) FanOut(ctx context.Context, limit int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `UPDATE outbox_events SET dispatched_at=now()
+		WHERE id IN (SELECT id FROM outbox_events WHERE dispatched_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING id`, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT s.id, e.id, e.type, jsonb_build_object('id', e.id, 'type', e.type, 'created_at', e.created_at, 'data', e.data)
+		FROM outbox_events e JOIN webhook_subscriptions s ON s.active AND e.type = ANY(s.events)
+		WHERE e.id = ANY($1) ORDER BY e.id, s.id`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), tx.Commit()
+}
+
+// PruneOutbox deletes events dispatched before the given moment. Their
+// deliveries keep a copy of the payload.
+func (r *Webhooks) PruneOutbox(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM outbox_events WHERE dispatched_at < $1", before)
+	return err
+}
+
+// ClaimDue picks up to limit pending deliveries whose time has come and
+// pushes their next attempt lease into the future, so that no other worker
+// sends them meanwhile. Deliveries of inactive subscriptions are left out
+// before the limit applies, so that they can't take up every batch.
+func (r *Webhooks) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `UPDATE webhook_deliveries d SET next_attempt_at = now() + $2::float8 * interval '1 millisecond'
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT due.id FROM webhook_deliveries due JOIN webhook_subscriptions sub ON sub.id = due.subscription_id AND sub.active
+			WHERE due.status='pending' AND due.next_attempt_at <= now()
+			ORDER BY due.next_attempt_at LIMIT $1 FOR UPDATE OF due SKIP LOCKED)
+		RETURNING `+deliveryColumns+", s.url, s.secret", limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var url, secret string
+		delivery, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.URL, delivery.Secret = url, secret
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *Webhooks) MarkSucceeded(ctx context.Context, id int64, statusCode int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries SET status=$1, attempts=attempts+1, last_status_code=$2,
+		last_error=NULL, delivered_at=now() WHERE id=$3`, domain.DeliverySucceeded, statusCode, id)
+
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt and schedules the next one, or
+// gives up on the delivery when next is nil.
+func (r *Webhooks) MarkAttemptFailed(ctx context.Context, id int64, statusCode int, reason string, next *time.Time) error {
+	status, nextAttempt := domain.DeliveryPending, time.Now()
+	if next == nil {
+		status = domain.DeliveryFailed
+	} else {
+		nextAttempt = *next
+	}
+
+	_, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries SET status=$1, attempts=attempts+1, last_status_code=$2,
+		last_error=$3, next_attempt_at=$4 WHERE id=$5`,
+		status, sql.NullInt64{Int64: int64(statusCode), Valid: statusCode != 0}, reason, nextAttempt, id)
+
+	return err
+}
+
+func (r *Webhooks) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+deliveryColumns+` FROM webhook_deliveries d
+		WHERE d.subscription_id=$1 ORDER BY d.id DESC LIMIT $2 OFFSET $3`, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *Webhooks) GetDelivery(ctx context.Context, subscriptionID, id int64) (domain.WebhookDelivery, error) {
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, "SELECT "+deliveryColumns+` FROM webhook_deliveries d
+		WHERE d.id=$1 AND d.subscription_id=$2`, id, subscriptionID))
+	if err == sql.ErrNoRows {
+		return delivery, domain.ErrDeliveryNotFound
+	}
+
+	return delivery, err
+}
+
+// Redeliver queues a fresh copy of a past delivery and returns its ID. The
+// original is kept for the log.
+func (r *Webhooks) Redeliver(ctx context.Context, subscriptionID, id int64) (int64, error) {
+	var newID int64
+	err := r.db.QueryRowContext(ctx, `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, redelivery_of)
+		SELECT subscription_id, event_id, event_type, payload, id FROM webhook_deliveries WHERE id=$1 AND subscription_id=$2
+		RETURNING id`, id, subscriptionID).Scan(&newID)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrDeliveryNotFound
+	}
+
+	return newID, err
+}
+
+func scanDelivery(row rowScanner, extra ...interface{}) (domain.WebhookDelivery, error) {
+	var (
+		delivery     domain.WebhookDelivery
+		payload      []byte
+		redeliveryOf sql.NullInt64
+		deliveredAt  sql.NullTime
+	)
+
+	dest := append([]interface{}{&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError,
+		&redeliveryOf, &delivery.CreatedAt, &deliveredAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return delivery, err
+	}
+
+	delivery.Payload = payload
+
+	if redeliveryOf.Valid {
+		delivery.RedeliveryOf = &redeliveryOf.Int64
+	}
+
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+
+	return delivery, nil
+}
+
+func (r *Webhooks) Create(ctx context.Context, sub domain.WebhookSubscription) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `INSERT INTO webhook_subscriptions (url, secret, events, active, created_by, created_at)
+		values ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		sub.URL, sub.Secret, pq.Array(webhookEventNames(sub.Events)), sub.Active, nullID(sub.CreatedBy), sub.CreatedAt).Scan(&id)
+
+	return id, err
+}
+
+func (r *Webhooks) GetByID(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	sub, err := scanWebhook(r.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhook_subscriptions WHERE id=$1", id))
+	if err == sql.ErrNoRows {
+		return sub, domain.ErrWebhookNotFound
+	}
+
+	return sub, err
+}
+
+func (r *Webhooks) GetAll(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]domain.WebhookSubscription, 0)
+	for rows.Next() {
+		sub, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+func (r *Webhooks) Update(ctx context.Context, id int64, inp domain.UpdateWebhookInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if inp.URL != nil {
+		setValues = append(setValues, fmt.Sprintf("url=$%d", argId))
+		args = append(args, *inp.URL)
+		argId++
+	}
+
+	if inp.Events != nil {
+		setValues = append(setValues, fmt.Sprintf("events=$%d", argId))
+		args = append(args, pq.Array(webhookEventNames(*inp.Events)))
+		argId++
+	}
+
+	if inp.Active != nil {
+		setValues = append(setValues, fmt.Sprintf("active=$%d", argId))
+		args = append(args, *inp.Active)
+		argId++
+	}
+
+	if len(setValues) == 0 {
+		_, err := r.GetByID(ctx, id)
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE webhook_subscriptions SET %s WHERE id=$%d", strings.Join(setValues, ", "), argId)
+	args = append(args, id)
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if err := webhookAffected(res); err != nil {
+		return err
+	}
+
+	// pending deliveries of an inactive subscription would never be sent;
+	// they can still be redelivered once it is active again
+	if inp.Active != nil && !*inp.Active {
+		_, err := tx.ExecContext(ctx, "UPDATE webhook_deliveries SET status=$1, last_error=$2 WHERE subscription_id=$3 AND status=$4",
+			domain.DeliveryFailed, inactiveSubscriptionError, id, domain.DeliveryPending)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Webhooks) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	return webhookAffected(res)
+}
+
+func scanWebhook(row rowScanner) (domain.WebhookSubscription, error) {
+	var (
+		sub    domain.WebhookSubscription
+		events []string
+	)
+
+	if err := row.Scan(&sub.ID, &sub.URL, &sub.Secret, pq.Array(&events), &sub.Active, &sub.CreatedBy, &sub.CreatedAt); err != nil {
+		return sub, err
+	}
+
+	sub.Events = make([]domain.WebhookEvent, len(events))
+	for i, event := range events {
+		sub.Events[i] = domain.WebhookEvent(event)
+	}
+
+	return sub, nil
+}
+
+func webhookEventNames(events []domain.WebhookEvent) []string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+
+	return names
+}
+
+func webhookAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package psql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func TestWebhooksInactiveSubscription(t *testing.T) {
+	db := testDB(t, "000012_webhooks")
+	repo := NewWebhooks(db)
+	ctx := context.Background()
+
+	events := []domain.WebhookEvent{domain.EventBookCreated}
+
+	inactive, err := repo.Create(ctx, domain.WebhookSubscription{URL: "http://inactive", Secret: "s", Events: events, Active: true, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := repo.Create(ctx, domain.WebhookSubscription{URL: "http://active", Secret: "s", Events: events, Active: true, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a full batch of older deliveries of the subscription that gets
+	// deactivated, then a newer one of the active subscription
+	const batch = 3
+
+	for i := 0; i < batch; i++ {
+		_, err := db.Exec(`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at)
+			values ($1, $2, 'book.created', '{}', now() - interval '1 hour')`, inactive, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := db.Exec(`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		values ($1, 100, 'book.created', '{}')`, active); err != nil {
+		t.Fatal(err)
+	}
+
+	deactivate := false
+	if err := repo.Update(ctx, inactive, domain.UpdateWebhookInput{Active: &deactivate}); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := repo.ClaimDue(ctx, batch, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deliveries) != 1 || deliveries[0].SubscriptionID != active {
+		t.Fatalf("claimed %+v, want the delivery of subscription %d", deliveries, active)
+	}
+
+	parked, err := repo.ListDeliveries(ctx, inactive, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, delivery := range parked {
+		if delivery.Status != domain.DeliveryFailed || delivery.LastError != inactiveSubscriptionError {
+			t.Errorf("delivery %d of the inactive subscription is %s (%q), want %s (%q)",
+				delivery.ID, delivery.Status, delivery.LastError, domain.DeliveryFailed, inactiveSubscriptionError)
+		}
+	}
+}
+
+func TestWebhooksClaimDueSkipsInactiveBeforeLimit(t *testing.T) {
+	db := testDB(t, "000012_webhooks")
+	repo := NewWebhooks(db)
+	ctx := context.Background()
+
+	events := []domain.WebhookEvent{domain.EventBookCreated}
+
+	inactive, err := repo.Create(ctx, domain.WebhookSubscription{URL: "http://inactive", Secret: "s", Events: events, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := repo.Create(ctx, domain.WebhookSubscription{URL: "http://active", Secret: "s", Events: events, Active: true, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pending deliveries left from before the subscription was deactivated,
+	// e.g. by an older version that didn't give up on them
+	if _, err := db.Exec(`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at)
+		SELECT $1, n, 'book.created', '{}', now() - interval '1 hour' FROM generate_series(1, 5) n`, inactive); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		values ($1, 100, 'book.created', '{}')`, active); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := repo.ClaimDue(ctx, 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deliveries) != 1 || deliveries[0].SubscriptionID != active {
+		t.Fatalf("claimed %+v, want the delivery of subscription %d", deliveries, active)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+const (
+	defaultUsersLimit = 50
+	maxUsersLimit     = 200
+)
+
+// SearchUsers lists users for admins. Like everything admins do with users,
+// it is audited, as it discloses their email addresses.
+func (s *Users) SearchUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUsersLimit
+	}
+
+	if filter.Limit > maxUsersLimit {
+		filter.Limit = maxUsersLimit
+	}
+
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	if filter.Role != "" && !filter.Role.Valid() {
+		return nil, domain.ErrInvalidRole
+	}
+
+	users, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action: domain.AuditUsersSearched,
+		Entity: domain.AuditEntityUser,
+		After: auditJSON(map[string]interface{}{
+			"query":  filter.Query,
+			"role":   filter.Role,
+			"limit":  filter.Limit,
+			"offset": filter.Offset,
+		}),
+	})
+
+	return users, nil
+}
+
+func (s *Users) GetUser(ctx context.Context, id int64) (domain.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditUserViewed,
+		Entity:   domain.AuditEntityUser,
+		EntityID: id,
+	})
+
+	return user, nil
+}
+
+// DisableUser keeps the user from signing in and revokes their sessions.
+// Access tokens already issued stay valid until they expire.
+func (s *Users) DisableUser(ctx context.Context, id int64) error {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.repo.SetDisabledAt(ctx, id, &now); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionsRepo.DeleteByUser(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditUserDisabled,
+		Entity:   domain.AuditEntityUser,
+		EntityID: id,
+	})
+
+	return nil
+}
+
+func (s *Users) EnableUser(ctx context.Context, id int64) error {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if user.DisabledAt == nil {
+		return nil
+	}
+
+	if err := s.repo.SetDisabledAt(ctx, id, nil); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditUserEnabled,
+		Entity:   domain.AuditEntityUser,
+		EntityID: id,
+		Before:   auditJSON(map[string]*time.Time{"disabled_at": user.DisabledAt}),
+	})
+
+	return nil
+}
+
+// ForcePasswordReset replaces the password of the user with a random one,
+// revokes their sessions and sends them a reset link, for accounts that may
+// have been taken over.
+func (s *Users) ForcePasswordReset(ctx context.Context, id int64) error {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	random, err := newNonce()
+	if err != nil {
+		return err
+	}
+
+	password, err := s.hasher.Hash(random)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetPassword(ctx, id, password); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionsRepo.DeleteByUser(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditResetForced,
+		Entity:   domain.AuditEntityUser,
+		EntityID: id,
+	})
+
+	return s.sendPasswordReset(ctx, user)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry domain.AuditEntry) error
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+// Auditor records audit entries on behalf of other services.
+type Auditor interface {
+	Record(ctx context.Context, entry domain.AuditEntry)
+}
+
+type Audit struct {
+	repo AuditRepository
+}
+
+func NewAudit(repo AuditRepository) *Audit {
+	return &Audit{
+		repo: repo,
+	}
+}
+
+// Record completes the entry with the actor found in ctx and stores it.
+// Failures are logged rather than returned, so that auditing never breaks the
+// operation being audited.
+func (a *Audit) Record(ctx context.Context, entry domain.AuditEntry) {
+	actor := domain.ActorFromContext(ctx)
+	if entry.ActorID == 0 {
+		entry.ActorID = actor.UserID
+	}
+
+	entry.IP = actor.IP
+	entry.UserAgent = actor.UserAgent
+	entry.RequestID = actor.RequestID
+	entry.CreatedAt = time.Now()
+
+	if err := a.repo.Create(ctx, entry); err != nil {
+		log.WithFields(log.Fields{
+			"action":     entry.Action,
+			"entity":     entry.Entity,
+			"entity_id":  entry.EntityID,
+			"request_id": entry.RequestID,
+		}).Errorf("failed to write audit entry: %s", err)
+	}
+}
+
+func (a *Audit) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return a.repo.List(ctx, filter)
+}
+
+// auditJSON marshals v for the before/after fields of an audit entry.
+func auditJSON(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Errorf("failed to marshal audit payload: %s", err)
+		return nil
+	}
+
+	return data
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type AuthorsRepository interface {
+	Create(ctx context.Context, author domain.Author) (int64, error)
+	GetByID(ctx context.Context, id int64) (domain.Author, error)
+	GetAll(ctx context.Context, name string) ([]domain.Author, error)
+	Update(ctx context.Context, id int64, inp domain.UpdateAuthorInput) error
+	Delete(ctx context.Context, id int64) error
+	Merge(ctx context.Context, target int64, from []int64) error
+}
+
+// BooksFinder lists books, with their author credits attached.
+type BooksFinder interface {
+	GetAll(ctx context.Context, filter domain.BookFilter) ([]domain.Book, error)
+}
+
+type Authors struct {
+	repo  AuthorsRepository
+	books BooksFinder
+}
+
+func NewAuthors(repo AuthorsRepository, books BooksFinder) *Authors {
+	return &Authors{
+		repo:  repo,
+		books: books,
+	}
+}
+
+func (a *Authors) Create(ctx context.Context, inp domain.AuthorInput) (domain.Author, error) {
+	author := domain.Author{
+		Name:      inp.Name,
+		Bio:       inp.Bio,
+		CreatedAt: time.Now(),
+	}
+
+	id, err := a.repo.Create(ctx, author)
+	if err != nil {
+		return author, err
+	}
+
+	author.ID = id
+
+	return author, nil
+}
+
+func (a *Authors) GetByID(ctx context.Context, id int64) (domain.Author, error) {
+	return a.repo.GetByID(ctx, id)
+}
+
+func (a *Authors) GetAll(ctx context.Context, name string) ([]domain.Author, error) {
+	return a.repo.GetAll(ctx, name)
+}
+
+func (a *Authors) Update(ctx context.Context, id int64, inp domain.UpdateAuthorInput) error {
+	return a.repo.Update(ctx, id, inp)
+}
+
+func (a *Authors) Delete(ctx context.Context, id int64) error {
+	return a.repo.Delete(ctx, id)
+}
+
+// Merge folds duplicate authors into target, e.g. "Tolkien" into
+// "J.R.R. Tolkien", moving all their book credits over.
+func (a *Authors) Merge(ctx context.Context, target int64, inp domain.MergeAuthorsInput) error {
+	for _, id := range inp.From {
+		if id == target {
+			return domain.ErrAuthorSelfMerge
+		}
+	}
+
+	if _, err := a.repo.GetByID(ctx, target); err != nil {
+		return err
+	}
+
+	return a.repo.Merge(ctx, target, inp.From)
+}
+
+func (a *Authors) GetBooks(ctx context.Context, id int64) ([]domain.Book, error) {
+	if _, err := a.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return a.books.GetAll(ctx, domain.BookFilter{AuthorID: &id})
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+// BookEvents fans committed book changes out to live subscribers. It keeps
+// the latest events in a bounded buffer, so that reconnecting clients can
+// catch up on what they missed.
+type BookEvents struct {
+	mu          sync.Mutex
+	seq         uint64
+	buffer      []domain.BookEvent
+	next        int
+	subscribers map[chan domain.BookEvent]struct{}
+	queueSize   int
+}
+
+func NewBookEvents(replaySize, queueSize int) *BookEvents {
+	return &BookEvents{
+		buffer:      make([]domain.BookEvent, 0, replaySize),
+		subscribers: make(map[chan domain.BookEvent]struct{}),
+		queueSize:   queueSize,
+	}
+}
+
+// Publish never blocks: subscribers whose queue is full are dropped.
+func (e *BookEvents) Publish(eventType domain.WebhookEvent, book domain.Book) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.seq++
+	event := domain.BookEvent{
+		ID:        e.seq,
+		Type:      eventType,
+		Book:      book,
+		CreatedAt: time.Now(),
+	}
+
+	if len(e.buffer) < cap(e.buffer) {
+		e.buffer = append(e.buffer, event)
+	} else if cap(e.buffer) > 0 {
+		e.buffer[e.next] = event
+		e.next = (e.next + 1) % cap(e.buffer)
+	}
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe starts a subscription, delivering new events on the returned
+// channel until cancel is called. The channel is closed early if the
+// subscriber falls behind by more than its queue size.
+//
+// When resuming, the events published after lastID are returned for replay;
+// complete is false if some of them are no longer buffered, or lastID is
+// unknown, and the client has to reload.
+func (e *BookEvents) Subscribe(lastID uint64, resume bool) (replay []domain.BookEvent, complete bool,
+	events <-chan domain.BookEvent, cancel func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ch := make(chan domain.BookEvent, e.queueSize)
+	e.subscribers[ch] = struct{}{}
+
+	cancel = func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if _, ok := e.subscribers[ch]; ok {
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	if !resume || lastID == e.seq {
+		return nil, true, ch, cancel
+	}
+
+	if lastID > e.seq {
+		return nil, false, ch, cancel
+	}
+
+	buffered := e.ordered()
+	complete = len(buffered) > 0 && buffered[0].ID <= lastID+1
+
+	for _, event := range buffered {
+		if event.ID > lastID {
+			replay = append(replay, event)
+		}
+	}
+
+	return replay, complete, ch, cancel
+}
+
+// ordered returns the buffered events from oldest to newest.
+func (e *BookEvents) ordered() []domain.BookEvent {
+	if len(e.buffer) < cap(e.buffer) {
+		return e.buffer
+	}
+
+	return append(append([]domain.BookEvent{}, e.buffer[e.next:]...), e.buffer[:e.next]...)
+}
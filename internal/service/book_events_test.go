@@ -0,0 +1,130 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func eventIDs(events []domain.BookEvent) []uint64 {
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	return ids
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestBookEventsSubscribe(t *testing.T) {
+	tests := []struct {
+		name         string
+		replaySize   int
+		published    int
+		lastID       uint64
+		resume       bool
+		wantReplay   []uint64
+		wantComplete bool
+	}{
+		{"fresh subscription", 3, 5, 0, false, nil, true},
+		{"up to date", 3, 5, 5, true, nil, true},
+		{"resume within the buffer", 3, 5, 3, true, []uint64{4, 5}, true},
+		{"resume at the oldest buffered event", 3, 5, 2, true, []uint64{3, 4, 5}, true},
+		{"resume beyond the buffer", 3, 5, 1, true, []uint64{3, 4, 5}, false},
+		{"resume after wraparound", 3, 7, 4, true, []uint64{5, 6, 7}, true},
+		{"resume before anything was published", 3, 2, 0, true, []uint64{1, 2}, true},
+		{"unknown last ID after a restart", 3, 2, 10, true, nil, false},
+		{"no buffer", 0, 2, 1, true, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := NewBookEvents(tt.replaySize, 10)
+			for i := 0; i < tt.published; i++ {
+				events.Publish(domain.EventBookUpdated, domain.Book{ID: int64(i)})
+			}
+
+			replay, complete, _, cancel := events.Subscribe(tt.lastID, tt.resume)
+			defer cancel()
+
+			if got := eventIDs(replay); !equalIDs(got, tt.wantReplay) || complete != tt.wantComplete {
+				t.Errorf("Subscribe(%d, %t) = %v, %t, want %v, %t",
+					tt.lastID, tt.resume, got, complete, tt.wantReplay, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestBookEventsDeliversNewEvents(t *testing.T) {
+	events := NewBookEvents(3, 10)
+	events.Publish(domain.EventBookCreated, domain.Book{ID: 1})
+
+	_, _, ch, cancel := events.Subscribe(0, false)
+	defer cancel()
+
+	events.Publish(domain.EventBookUpdated, domain.Book{ID: 1})
+
+	event := <-ch
+	if event.ID != 2 || event.Type != domain.EventBookUpdated {
+		t.Errorf("got event %d %s, want 2 %s", event.ID, event.Type, domain.EventBookUpdated)
+	}
+}
+
+func TestBookEventsDropsSlowSubscriber(t *testing.T) {
+	events := NewBookEvents(3, 2)
+
+	_, _, slow, cancelSlow := events.Subscribe(0, false)
+	_, _, fast, cancelFast := events.Subscribe(0, false)
+	defer cancelFast()
+
+	for i := 0; i < 3; i++ {
+		events.Publish(domain.EventBookUpdated, domain.Book{ID: 1})
+		if i < 2 {
+			<-fast
+		}
+	}
+
+	var received []uint64
+	for event := range slow {
+		received = append(received, event.ID)
+	}
+
+	if !equalIDs(received, []uint64{1, 2}) {
+		t.Errorf("slow subscriber received %v before being closed, want [1 2]", received)
+	}
+
+	// cancelling after the drop must not close the channel again
+	cancelSlow()
+
+	if event, ok := <-fast; !ok || event.ID != 3 {
+		t.Errorf("fast subscriber got %d, %t, want event 3", event.ID, ok)
+	}
+}
+
+func TestBookEventsCancel(t *testing.T) {
+	events := NewBookEvents(3, 2)
+
+	_, _, ch, cancel := events.Subscribe(0, false)
+	cancel()
+	cancel()
+
+	if _, ok := <-ch; ok {
+		t.Fatal("channel still open after cancel")
+	}
+
+	// publishing to no one doesn't block or panic
+	events.Publish(domain.EventBookUpdated, domain.Book{ID: 1})
+}
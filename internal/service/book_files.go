@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/pkg/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// an EPUB is a zip archive whose first entry is an uncompressed file named
+// "mimetype" holding the media type
+var epubSignature = []byte("PK\x03\x04")
+
+const epubMimetypeOffset = 30
+
+type BookFilesRepository interface {
+	Create(ctx context.Context, file domain.BookFile) (int64, error)
+	GetByID(ctx context.Context, bookID, userID, id int64) (domain.BookFile, error)
+	List(ctx context.Context, bookID, userID int64) ([]domain.BookFile, error)
+	Delete(ctx context.Context, id int64) error
+	HashInUse(ctx context.Context, sha256 string) (bool, error)
+	LockHash(ctx context.Context, sha256 string) error
+}
+
+// BookFiles keeps the e-book files users attach to books. The content is
+// stored once per distinct SHA-256, however many users upload it. Whether a
+// blob is stored or deleted is decided in a transaction holding the lock of
+// its hash, so that an upload can't count on a blob a delete is removing.
+type BookFiles struct {
+	repo    BookFilesRepository
+	books   BookGetter
+	blobs   BlobStore
+	tx      Transactor
+	maxSize int64
+}
+
+func NewBookFiles(repo BookFilesRepository, books BookGetter, blobs BlobStore, tx Transactor, maxSize int64) *BookFiles {
+	return &BookFiles{
+		repo:    repo,
+		books:   books,
+		blobs:   blobs,
+		tx:      tx,
+		maxSize: maxSize,
+	}
+}
+
+// MaxSize is the largest file upload accepted, in bytes.
+func (s *BookFiles) MaxSize() int64 {
+	return s.maxSize
+}
+
+func (s *BookFiles) List(ctx context.Context, bookID int64) ([]domain.BookFile, error) {
+	if _, err := s.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.List(ctx, bookID, domain.ActorFromContext(ctx).UserID)
+}
+
+// Upload stores the file read from r for the current user. The content is
+// spooled to a temporary file while it is hashed, so large files are never
+// held in memory.
+func (s *BookFiles) Upload(ctx context.Context, bookID int64, name string, r io.Reader) (domain.BookFile, error) {
+	if _, err := s.books.GetByID(ctx, bookID); err != nil {
+		return domain.BookFile{}, err
+	}
+
+	tmp, err := os.CreateTemp("", "book-file-*")
+	if err != nil {
+		return domain.BookFile{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return domain.BookFile{}, err
+	}
+
+	if size > s.maxSize {
+		return domain.BookFile{}, domain.ErrFileTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return domain.BookFile{}, err
+	}
+
+	contentType, err := detectBookFileType(head[:n])
+	if err != nil {
+		return domain.BookFile{}, err
+	}
+
+	file := domain.BookFile{
+		BookID:      bookID,
+		UserID:      domain.ActorFromContext(ctx).UserID,
+		Name:        cleanFileName(name, contentType),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		CreatedAt:   time.Now(),
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockHash(ctx, file.SHA256); err != nil {
+			return err
+		}
+
+		inUse, err := s.repo.HashInUse(ctx, file.SHA256)
+		if err != nil {
+			return err
+		}
+
+		if !inUse {
+			if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+
+			if err := s.blobs.Put(ctx, fileBlobKey(file.SHA256), tmp, size, contentType); err != nil {
+				return err
+			}
+		}
+
+		file.ID, err = s.repo.Create(ctx, file)
+
+		return err
+	})
+
+	return file, err
+}
+
+// Open returns a file of the current user along with its content.
+func (s *BookFiles) Open(ctx context.Context, bookID, id int64) (domain.BookFile, io.ReadSeekCloser, error) {
+	file, err := s.repo.GetByID(ctx, bookID, domain.ActorFromContext(ctx).UserID, id)
+	if err != nil {
+		return file, nil, err
+	}
+
+	blob, _, err := s.blobs.Open(ctx, fileBlobKey(file.SHA256))
+	if errors.Is(err, storage.ErrNotFound) {
+		return file, nil, domain.ErrFileNotFound
+	}
+
+	return file, blob, err
+}
+
+// Delete removes a file of the current user, and its content once no other
+// file shares it.
+func (s *BookFiles) Delete(ctx context.Context, bookID, id int64) error {
+	file, err := s.repo.GetByID(ctx, bookID, domain.ActorFromContext(ctx).UserID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockHash(ctx, file.SHA256); err != nil {
+			return err
+		}
+
+		if err := s.repo.Delete(ctx, file.ID); err != nil {
+			return err
+		}
+
+		return s.deleteUnusedBlob(ctx, file.SHA256)
+	})
+}
+
+// CleanUpPurged deletes the content of the files of purged books that no
+// other file shares.
+func (s *BookFiles) CleanUpPurged(ctx context.Context, books []domain.PurgedBook) {
+	for _, book := range books {
+		s.deleteUnusedBlobs(ctx, book.FileHashes)
+	}
+}
+
+// CleanUpPurgedUsers deletes the content of the files uploaded by purged
+// users that no other file shares.
+func (s *BookFiles) CleanUpPurgedUsers(ctx context.Context, users []domain.PurgedUser) {
+	for _, user := range users {
+		s.deleteUnusedBlobs(ctx, user.FileHashes)
+	}
+}
+
+// deleteUnusedBlobs deletes the content of files already removed, taking the
+// lock of each hash in turn.
+func (s *BookFiles) deleteUnusedBlobs(ctx context.Context, hashes []string) {
+	for _, hash := range hashes {
+		if err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := s.repo.LockHash(ctx, hash); err != nil {
+				return err
+			}
+
+			return s.deleteUnusedBlob(ctx, hash)
+		}); err != nil {
+			log.WithField("sha256", hash).Errorf("failed to delete file blob: %s", err)
+		}
+	}
+}
+
+// deleteUnusedBlob deletes the content with the given hash unless a file
+// still refers to it. The lock of the hash must be held.
+func (s *BookFiles) deleteUnusedBlob(ctx context.Context, hash string) error {
+	inUse, err := s.repo.HashInUse(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	if !inUse {
+		// the file is gone either way, a blob left behind only wastes space
+		if err := s.blobs.Delete(ctx, fileBlobKey(hash)); err != nil {
+			log.WithField("sha256", hash).Errorf("failed to delete file blob: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func detectBookFileType(head []byte) (string, error) {
+	if http.DetectContentType(head) == domain.FileTypePDF {
+		return domain.FileTypePDF, nil
+	}
+
+	mimetype := []byte("mimetype" + domain.FileTypeEPUB)
+	if bytes.HasPrefix(head, epubSignature) && len(head) >= epubMimetypeOffset+len(mimetype) &&
+		bytes.Equal(head[epubMimetypeOffset:epubMimetypeOffset+len(mimetype)], mimetype) {
+		return domain.FileTypeEPUB, nil
+	}
+
+	return "", domain.ErrFileType
+}
+
+// cleanFileName drops any directory part of the name given by the client and
+// makes sure it ends with the extension of the detected type.
+func cleanFileName(name, contentType string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "book"
+	}
+
+	if len(name) > 200 {
+		name = strings.ToValidUTF8(name[:200], "")
+	}
+
+	ext := ".pdf"
+	if contentType == domain.FileTypeEPUB {
+		ext = ".epub"
+	}
+
+	if !strings.EqualFold(filepath.Ext(name), ext) {
+		name += ext
+	}
+
+	return name
+}
+
+func fileBlobKey(sha256 string) string {
+	return "files/" + sha256[:2] + "/" + sha256
+}
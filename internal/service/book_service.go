@@ -2,25 +2,94 @@ package service
 
 import (
 	"context"
-	"github.com/crud-app/internal/domain"
+	"encoding/json"
+	"strings"
 	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type BooksRepository interface {
-	CreateBook(ctx context.Context, book domain.Book) error
+	CreateBook(ctx context.Context, book domain.Book) (int64, error)
 	GetByID(ctx context.Context, id int64) (domain.Book, error)
-	GetAll(ctx context.Context) ([]domain.Book, error)
+	GetByISBN(ctx context.Context, isbn13 string) (domain.Book, error)
+	GetAll(ctx context.Context, filter domain.BookFilter) ([]domain.Book, error)
+	Stream(ctx context.Context, filter domain.BookFilter, fn func(domain.Book) error) error
 	Delete(ctx context.Context, id int64) error
 	Update(ctx context.Context, id int64, inp domain.UpdateBookInput) error
+	GetTrash(ctx context.Context) ([]domain.Book, error)
+	Restore(ctx context.Context, id int64) error
+	Purge(ctx context.Context, before time.Time) ([]domain.PurgedBook, error)
+	Facets(ctx context.Context, filter domain.BookFilter) (domain.BookFacets, error)
+}
+
+type BookRevisionsRepository interface {
+	Create(ctx context.Context, authorID int64, book domain.Book) (int, error)
+	List(ctx context.Context, bookID int64) ([]domain.BookRevision, error)
+	Get(ctx context.Context, bookID int64, revision int) (domain.BookRevision, error)
+}
+
+type BookAuthorsRepository interface {
+	GetByID(ctx context.Context, id int64) (domain.Author, error)
+	FindOrCreate(ctx context.Context, name string) (int64, error)
+	GetForBooks(ctx context.Context, bookIDs []int64) (map[int64][]domain.BookAuthor, error)
+	SetForBook(ctx context.Context, bookID int64, authors []domain.BookAuthor) error
+}
+
+type BookGenresRepository interface {
+	GetForBooks(ctx context.Context, bookIDs []int64) (map[int64][]domain.Genre, error)
+	SetForBook(ctx context.Context, bookID int64, genreIDs []int64) error
+}
+
+type BookTagsRepository interface {
+	Add(ctx context.Context, bookID, userID int64, tags []string) error
+	Remove(ctx context.Context, bookID int64, tag string) error
+	GetForBooks(ctx context.Context, bookIDs []int64) (map[int64][]string, error)
+}
+
+// Transactor runs fn in a transaction that every repository called with the
+// ctx passed to fn takes part in.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Outbox queues events for webhook subscribers.
+type Outbox interface {
+	Enqueue(ctx context.Context, event domain.OutboxEvent) error
+}
+
+// EventPublisher notifies live clients of committed book changes.
+type EventPublisher interface {
+	Publish(eventType domain.WebhookEvent, book domain.Book)
 }
 
 type BooksService struct {
-	repo BooksRepository
+	repo      BooksRepository
+	revisions BookRevisionsRepository
+	authors   BookAuthorsRepository
+	genres    BookGenresRepository
+	tags      BookTagsRepository
+	audit     Auditor
+	tx        Transactor
+	outbox    Outbox
+	events    EventPublisher
 }
 
-func NewBookManager(repo BooksRepository) *BooksService {
+func NewBookManager(repo BooksRepository, revisions BookRevisionsRepository, authors BookAuthorsRepository,
+	genres BookGenresRepository, tags BookTagsRepository, audit Auditor, tx Transactor, outbox Outbox,
+	events EventPublisher) *BooksService {
 	return &BooksService{
-		repo: repo,
+		repo:      repo,
+		revisions: revisions,
+		authors:   authors,
+		genres:    genres,
+		tags:      tags,
+		audit:     audit,
+		tx:        tx,
+		outbox:    outbox,
+		events:    events,
 	}
 }
 
@@ -29,21 +98,643 @@ func (b *BooksService) Create(ctx context.Context, book domain.Book) error {
 		book.PublishDate = time.Now()
 	}
 
-	return b.repo.CreateBook(ctx, book)
+	if err := book.NormalizeISBN(); err != nil {
+		return err
+	}
+
+	err := b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		authors, err := b.resolveAuthors(ctx, book.Authors, book.Author)
+		if err != nil {
+			return err
+		}
+
+		book.Authors = authors
+		if book.Author == "" {
+			book.Author = creditLine(authors)
+		}
+
+		id, err := b.repo.CreateBook(ctx, book)
+		if err != nil {
+			return err
+		}
+
+		book.ID = id
+		if err := b.authors.SetForBook(ctx, id, authors); err != nil {
+			return err
+		}
+
+		if _, err := b.revisions.Create(ctx, domain.ActorFromContext(ctx).UserID, book); err != nil {
+			return err
+		}
+
+		return b.publish(ctx, domain.EventBookCreated, book)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.events.Publish(domain.EventBookCreated, book)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookCreated,
+		Entity:   domain.AuditEntityBook,
+		EntityID: book.ID,
+		After:    auditJSON(book),
+	})
+
+	return nil
 }
 
 func (b *BooksService) GetByID(ctx context.Context, id int64) (domain.Book, error) {
-	return b.repo.GetByID(ctx, id)
+	book, err := b.repo.GetByID(ctx, id)
+	if err != nil {
+		return book, err
+	}
+
+	books := []domain.Book{book}
+	if err := b.attachRelations(ctx, books); err != nil {
+		return book, err
+	}
+
+	return books[0], nil
+}
+
+// GetByISBN finds a book by either its ISBN-10 or ISBN-13, with or without
+// hyphens.
+func (b *BooksService) GetByISBN(ctx context.Context, isbn string) (domain.Book, error) {
+	isbn13, _, err := domain.NormalizeISBN(isbn)
+	if err != nil {
+		return domain.Book{}, err
+	}
+
+	book, err := b.repo.GetByISBN(ctx, isbn13)
+	if err != nil {
+		return book, err
+	}
+
+	books := []domain.Book{book}
+	if err := b.attachRelations(ctx, books); err != nil {
+		return book, err
+	}
+
+	return books[0], nil
+}
+
+func (b *BooksService) GetAll(ctx context.Context, filter domain.BookFilter) ([]domain.Book, error) {
+	books, err := b.repo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.attachRelations(ctx, books); err != nil {
+		return nil, err
+	}
+
+	return books, nil
 }
 
-func (b *BooksService) GetAll(ctx context.Context) ([]domain.Book, error) {
-	return b.repo.GetAll(ctx)
+func (b *BooksService) Facets(ctx context.Context, filter domain.BookFilter) (domain.BookFacets, error) {
+	return b.repo.Facets(ctx, filter)
+}
+
+// attachRelations loads authors, genres and tags of all books at once, rather
+// than querying them book by book.
+func (b *BooksService) attachRelations(ctx context.Context, books []domain.Book) error {
+	ids := make([]int64, len(books))
+	for i := range books {
+		ids[i] = books[i].ID
+	}
+
+	authors, err := b.authors.GetForBooks(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	genres, err := b.genres.GetForBooks(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	tags, err := b.tags.GetForBooks(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		books[i].Authors = authors[books[i].ID]
+		books[i].Genres = genres[books[i].ID]
+		books[i].Tags = tags[books[i].ID]
+	}
+
+	return nil
+}
+
+func (b *BooksService) SetGenres(ctx context.Context, id int64, genreIDs []int64) error {
+	var before, after domain.Book
+
+	err := b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = b.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		if err := b.genres.SetForBook(ctx, id, genreIDs); err != nil {
+			return err
+		}
+
+		after, err = b.recordUpdate(ctx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	b.events.Publish(domain.EventBookUpdated, after)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookGenres,
+		Entity:   domain.AuditEntityBook,
+		EntityID: id,
+		Before:   auditJSON(before.Genres),
+		After:    auditJSON(genreIDs),
+	})
+
+	return nil
+}
+
+func (b *BooksService) GetTags(ctx context.Context, id int64) ([]string, error) {
+	book, err := b.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if book.Tags == nil {
+		return []string{}, nil
+	}
+
+	return book.Tags, nil
+}
+
+func (b *BooksService) AddTags(ctx context.Context, id int64, tags []string) error {
+	if _, err := b.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = domain.NormalizeTag(tag); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	if len(normalized) == 0 {
+		return nil
+	}
+
+	var after domain.Book
+
+	err := b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.tags.Add(ctx, id, domain.ActorFromContext(ctx).UserID, normalized); err != nil {
+			return err
+		}
+
+		var err error
+		after, err = b.recordUpdate(ctx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	b.events.Publish(domain.EventBookUpdated, after)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookTagged,
+		Entity:   domain.AuditEntityBook,
+		EntityID: id,
+		After:    auditJSON(normalized),
+	})
+
+	return nil
+}
+
+func (b *BooksService) RemoveTag(ctx context.Context, id int64, tag string) error {
+	if _, err := b.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	tag = domain.NormalizeTag(tag)
+
+	var after domain.Book
+
+	err := b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.tags.Remove(ctx, id, tag); err != nil {
+			return err
+		}
+
+		var err error
+		after, err = b.recordUpdate(ctx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	b.events.Publish(domain.EventBookUpdated, after)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookUntagged,
+		Entity:   domain.AuditEntityBook,
+		EntityID: id,
+		Before:   auditJSON(tag),
+	})
+
+	return nil
+}
+
+// Export passes every book matching filter to fn in ID order without buffering.
+func (b *BooksService) Export(ctx context.Context, filter domain.BookFilter, fn func(domain.Book) error) error {
+	return b.repo.Stream(ctx, filter, fn)
 }
 
 func (b *BooksService) Delete(ctx context.Context, id int64) error {
-	return b.repo.Delete(ctx, id)
+	before, err := b.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return b.publish(ctx, domain.EventBookDeleted, before)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.events.Publish(domain.EventBookDeleted, before)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookDeleted,
+		Entity:   domain.AuditEntityBook,
+		EntityID: id,
+		Before:   auditJSON(before),
+	})
+
+	return nil
 }
 
 func (b *BooksService) Update(ctx context.Context, id int64, inp domain.UpdateBookInput) error {
-	return b.repo.Update(ctx, id, inp)
+	var before, after domain.Book
+
+	err := b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = b.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		if err := b.applyUpdate(ctx, before, inp); err != nil {
+			return err
+		}
+
+		if after, err = b.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		if _, err := b.revisions.Create(ctx, domain.ActorFromContext(ctx).UserID, after); err != nil {
+			return err
+		}
+
+		return b.publish(ctx, domain.EventBookUpdated, after)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.events.Publish(domain.EventBookUpdated, after)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookUpdated,
+		Entity:   domain.AuditEntityBook,
+		EntityID: id,
+		Before:   auditJSON(before),
+		After:    auditJSON(after),
+	})
+
+	return nil
+}
+
+func (b *BooksService) GetTrash(ctx context.Context) ([]domain.Book, error) {
+	return b.repo.GetTrash(ctx)
+}
+
+func (b *BooksService) Restore(ctx context.Context, id int64) error {
+	var after domain.Book
+
+	err := b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+
+		var err error
+		if after, err = b.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		return b.publish(ctx, domain.EventBookRestored, after)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.events.Publish(domain.EventBookRestored, after)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookRestored,
+		Entity:   domain.AuditEntityBook,
+		EntityID: id,
+		After:    auditJSON(after),
+	})
+
+	return nil
+}
+
+// TrashCleaner deletes what a service keeps outside the database, such as
+// blobs, for books removed for good.
+type TrashCleaner interface {
+	CleanUpPurged(ctx context.Context, books []domain.PurgedBook)
+}
+
+// PurgeTrash permanently removes books that have been in the trash for longer
+// than retention, then lets the cleaners delete what the books referred to.
+func (b *BooksService) PurgeTrash(ctx context.Context, retention time.Duration, cleaners ...TrashCleaner) (int64, error) {
+	before := time.Now().Add(-retention)
+
+	books, err := b.repo.Purge(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, cleaner := range cleaners {
+		cleaner.CleanUpPurged(ctx, books)
+	}
+
+	purged := int64(len(books))
+	if purged > 0 {
+		b.audit.Record(ctx, domain.AuditEntry{
+			Action: domain.AuditBookPurged,
+			Entity: domain.AuditEntityBook,
+			After: auditJSON(map[string]interface{}{
+				"purged":         purged,
+				"deleted_before": before,
+			}),
+		})
+	}
+
+	return purged, nil
+}
+
+// RunTrashPurger calls PurgeTrash every interval until ctx is cancelled.
+func (b *BooksService) RunTrashPurger(ctx context.Context, retention, interval time.Duration, cleaners ...TrashCleaner) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := b.PurgeTrash(ctx, retention, cleaners...)
+			if err != nil {
+				log.WithField("job", "trash_purger").Error(err)
+				continue
+			}
+
+			if purged > 0 {
+				log.WithField("job", "trash_purger").Infof("purged %d books", purged)
+			}
+		}
+	}
+}
+
+func (b *BooksService) GetRevisions(ctx context.Context, id int64) ([]domain.BookRevision, error) {
+	if _, err := b.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return b.revisions.List(ctx, id)
+}
+
+func (b *BooksService) GetRevision(ctx context.Context, id int64, revision int) (domain.BookRevision, error) {
+	return b.revisions.Get(ctx, id, revision)
+}
+
+func (b *BooksService) DiffRevisions(ctx context.Context, id int64, from, to int) (domain.BookRevisionDiff, error) {
+	fromRev, err := b.revisions.Get(ctx, id, from)
+	if err != nil {
+		return domain.BookRevisionDiff{}, err
+	}
+
+	toRev, err := b.revisions.Get(ctx, id, to)
+	if err != nil {
+		return domain.BookRevisionDiff{}, err
+	}
+
+	return domain.BookRevisionDiff{
+		BookID:  id,
+		From:    from,
+		To:      to,
+		Changes: domain.DiffBooks(fromRev.Book, toRev.Book),
+	}, nil
+}
+
+// RevertToRevision brings the book back to the state of the given revision.
+// History is never rewritten: the reverted state is stored as a new revision,
+// whose number is returned.
+func (b *BooksService) RevertToRevision(ctx context.Context, id int64, revision int) (int, error) {
+	target, err := b.revisions.Get(ctx, id, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		before, after domain.Book
+		newRevision   int
+	)
+
+	err = b.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = b.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		if err := b.applyUpdate(ctx, before, target.Book.UpdateInput()); err != nil {
+			return err
+		}
+
+		if after, err = b.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		if newRevision, err = b.revisions.Create(ctx, domain.ActorFromContext(ctx).UserID, after); err != nil {
+			return err
+		}
+
+		return b.publish(ctx, domain.EventBookUpdated, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	b.events.Publish(domain.EventBookUpdated, after)
+
+	b.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookReverted,
+		Entity:   domain.AuditEntityBook,
+		EntityID: id,
+		Before:   auditJSON(before),
+		After: auditJSON(map[string]interface{}{
+			"book":         after,
+			"reverted_to":  revision,
+			"new_revision": newRevision,
+		}),
+	})
+
+	return newRevision, nil
+}
+
+// publish queues a webhook event about the book. It has to be called within
+// the transaction of the change, so that the event exists if and only if the
+// change was committed.
+func (b *BooksService) publish(ctx context.Context, event domain.WebhookEvent, book domain.Book) error {
+	data, err := json.Marshal(map[string]interface{}{"book": book})
+	if err != nil {
+		return err
+	}
+
+	return b.outbox.Enqueue(ctx, domain.OutboxEvent{
+		Type:      event,
+		Data:      data,
+		CreatedAt: time.Now(),
+	})
+}
+
+// recordUpdate stores a revision of the book as changed so far within the
+// transaction in ctx and queues the matching webhook event. The caller
+// publishes the returned book to live clients once the transaction commits.
+func (b *BooksService) recordUpdate(ctx context.Context, id int64) (domain.Book, error) {
+	book, err := b.GetByID(ctx, id)
+	if err != nil {
+		return book, err
+	}
+
+	if _, err := b.revisions.Create(ctx, domain.ActorFromContext(ctx).UserID, book); err != nil {
+		return book, err
+	}
+
+	return book, b.publish(ctx, domain.EventBookUpdated, book)
+}
+
+// applyUpdate writes inp over the current state of the book, keeping the
+// author links and the author credit line in sync.
+func (b *BooksService) applyUpdate(ctx context.Context, current domain.Book, inp domain.UpdateBookInput) error {
+	if err := inp.NormalizeISBN(); err != nil {
+		return err
+	}
+
+	var authors []domain.BookAuthor
+
+	switch {
+	case inp.Authors != nil:
+		resolved, err := b.resolveAuthors(ctx, *inp.Authors, "")
+		if err != nil {
+			return err
+		}
+
+		authors = resolved
+		if inp.Author == nil {
+			credit := creditLine(authors)
+			inp.Author = &credit
+		}
+	case inp.Author != nil:
+		// the free-text author replaces the main authors, while translators and
+		// editors stay linked
+		resolved, err := b.resolveAuthors(ctx, nil, *inp.Author)
+		if err != nil {
+			return err
+		}
+
+		for _, author := range current.Authors {
+			if author.Role != domain.AuthorRoleAuthor {
+				resolved = append(resolved, author)
+			}
+		}
+
+		authors = resolved
+	}
+
+	if err := b.repo.Update(ctx, current.ID, inp); err != nil {
+		return err
+	}
+
+	if authors == nil {
+		return nil
+	}
+
+	return b.authors.SetForBook(ctx, current.ID, authors)
+}
+
+// resolveAuthors validates the given book credits and fills in author names.
+// When no credits are given, the free-text author is looked up by name, so
+// that equivalent spellings end up linked to the same author.
+func (b *BooksService) resolveAuthors(ctx context.Context, authors []domain.BookAuthor, name string) ([]domain.BookAuthor, error) {
+	resolved := make([]domain.BookAuthor, 0, len(authors))
+
+	if len(authors) == 0 {
+		if strings.TrimSpace(name) == "" {
+			return resolved, nil
+		}
+
+		id, err := b.authors.FindOrCreate(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(resolved, domain.BookAuthor{AuthorID: id, Name: name, Role: domain.AuthorRoleAuthor}), nil
+	}
+
+	for _, credit := range authors {
+		if credit.Role == "" {
+			credit.Role = domain.AuthorRoleAuthor
+		}
+
+		if !credit.Role.Valid() {
+			return nil, domain.ErrInvalidAuthorRole
+		}
+
+		author, err := b.authors.GetByID(ctx, credit.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+
+		credit.Name = author.Name
+		resolved = append(resolved, credit)
+	}
+
+	return resolved, nil
+}
+
+// creditLine renders the main authors of a book as a single string, kept in
+// the author column for clients that predate author links.
+func creditLine(authors []domain.BookAuthor) string {
+	names := make([]string, 0, len(authors))
+	for _, author := range authors {
+		if author.Role == domain.AuthorRoleAuthor {
+			names = append(names, author.Name)
+		}
+	}
+
+	return strings.Join(names, ", ")
 }
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/crud-app/internal/domain"
+)
+
+// The fakes embed the interfaces they stand in for, so that calling a method
+// a test didn't expect panics instead of silently doing nothing.
+
+type fakeBooks struct {
+	BooksRepository
+	books map[int64]domain.Book
+}
+
+func (f *fakeBooks) GetByID(ctx context.Context, id int64) (domain.Book, error) {
+	book, ok := f.books[id]
+	if !ok {
+		return domain.Book{}, domain.ErrBookNotFound
+	}
+
+	return book, nil
+}
+
+type fakeRevisions struct {
+	BookRevisionsRepository
+	created []domain.Book
+}
+
+func (f *fakeRevisions) Create(ctx context.Context, authorID int64, book domain.Book) (int, error) {
+	f.created = append(f.created, book)
+	return len(f.created), nil
+}
+
+type fakeBookAuthors struct{ BookAuthorsRepository }
+
+func (fakeBookAuthors) GetForBooks(ctx context.Context, ids []int64) (map[int64][]domain.BookAuthor, error) {
+	return nil, nil
+}
+
+type fakeBookGenres struct {
+	BookGenresRepository
+	genres map[int64][]int64
+}
+
+func (f *fakeBookGenres) SetForBook(ctx context.Context, bookID int64, genreIDs []int64) error {
+	f.genres[bookID] = genreIDs
+	return nil
+}
+
+func (f *fakeBookGenres) GetForBooks(ctx context.Context, ids []int64) (map[int64][]domain.Genre, error) {
+	res := make(map[int64][]domain.Genre)
+	for _, id := range ids {
+		for _, genreID := range f.genres[id] {
+			res[id] = append(res[id], domain.Genre{ID: genreID})
+		}
+	}
+
+	return res, nil
+}
+
+type fakeBookTags struct {
+	BookTagsRepository
+	tags map[int64][]string
+}
+
+func (f *fakeBookTags) Add(ctx context.Context, bookID, userID int64, tags []string) error {
+	f.tags[bookID] = append(f.tags[bookID], tags...)
+	return nil
+}
+
+func (f *fakeBookTags) Remove(ctx context.Context, bookID int64, tag string) error {
+	for i, t := range f.tags[bookID] {
+		if t == tag {
+			f.tags[bookID] = append(f.tags[bookID][:i], f.tags[bookID][i+1:]...)
+			return nil
+		}
+	}
+
+	return domain.ErrTagNotFound
+}
+
+func (f *fakeBookTags) GetForBooks(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	res := make(map[int64][]string)
+	for _, id := range ids {
+		res[id] = append([]string(nil), f.tags[id]...)
+	}
+
+	return res, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeOutbox struct{ events []domain.OutboxEvent }
+
+func (f *fakeOutbox) Enqueue(ctx context.Context, event domain.OutboxEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+type fakePublisher struct{ published []domain.Book }
+
+func (f *fakePublisher) Publish(eventType domain.WebhookEvent, book domain.Book) {
+	f.published = append(f.published, book)
+}
+
+type fakeAuditor struct{ entries []domain.AuditEntry }
+
+func (f *fakeAuditor) Record(ctx context.Context, entry domain.AuditEntry) {
+	f.entries = append(f.entries, entry)
+}
+
+type booksFixture struct {
+	service   *BooksService
+	revisions *fakeRevisions
+	outbox    *fakeOutbox
+	events    *fakePublisher
+	audit     *fakeAuditor
+}
+
+func newBooksFixture() booksFixture {
+	f := booksFixture{
+		revisions: &fakeRevisions{},
+		outbox:    &fakeOutbox{},
+		events:    &fakePublisher{},
+		audit:     &fakeAuditor{},
+	}
+
+	books := &fakeBooks{books: map[int64]domain.Book{1: {ID: 1, Title: "Dune"}}}
+	genres := &fakeBookGenres{genres: map[int64][]int64{}}
+	tags := &fakeBookTags{tags: map[int64][]string{1: {"classic"}}}
+
+	f.service = NewBookManager(books, f.revisions, fakeBookAuthors{}, genres, tags, f.audit, fakeTx{}, f.outbox, f.events)
+
+	return f
+}
+
+func TestBooksServiceRelationChanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(s *BooksService) error
+		wantGenres int
+		wantTags   []string
+	}{
+		{
+			name:       "set genres",
+			change:     func(s *BooksService) error { return s.SetGenres(context.Background(), 1, []int64{3, 4}) },
+			wantGenres: 2,
+			wantTags:   []string{"classic"},
+		},
+		{
+			name:     "add tags",
+			change:   func(s *BooksService) error { return s.AddTags(context.Background(), 1, []string{"Sci-Fi"}) },
+			wantTags: []string{"classic", "sci-fi"},
+		},
+		{
+			name:   "remove tag",
+			change: func(s *BooksService) error { return s.RemoveTag(context.Background(), 1, "Classic") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newBooksFixture()
+
+			if err := tt.change(f.service); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(f.revisions.created) != 1 || len(f.outbox.events) != 1 || len(f.events.published) != 1 {
+				t.Fatalf("got %d revisions, %d outbox events, %d live events, want 1 each",
+					len(f.revisions.created), len(f.outbox.events), len(f.events.published))
+			}
+
+			if f.outbox.events[0].Type != domain.EventBookUpdated {
+				t.Errorf("outbox event %q, want %q", f.outbox.events[0].Type, domain.EventBookUpdated)
+			}
+
+			book := f.revisions.created[0]
+			if len(book.Genres) != tt.wantGenres || len(book.Tags) != len(tt.wantTags) {
+				t.Fatalf("revision has genres %v and tags %v, want %d genres and tags %v",
+					book.Genres, book.Tags, tt.wantGenres, tt.wantTags)
+			}
+
+			for i, tag := range tt.wantTags {
+				if book.Tags[i] != tag {
+					t.Errorf("revision tags %v, want %v", book.Tags, tt.wantTags)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestBooksServiceRemoveMissingTag(t *testing.T) {
+	f := newBooksFixture()
+
+	err := f.service.RemoveTag(context.Background(), 1, "horror")
+	if !errors.Is(err, domain.ErrTagNotFound) {
+		t.Fatalf("RemoveTag error = %v, want %v", err, domain.ErrTagNotFound)
+	}
+
+	if len(f.revisions.created) != 0 || len(f.events.published) != 0 || len(f.audit.entries) != 0 {
+		t.Errorf("failed change left %d revisions, %d live events and %d audit entries",
+			len(f.revisions.created), len(f.events.published), len(f.audit.entries))
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/pkg/storage"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	maxCoverPixels     = 40_000_000
+	minCoverSide       = 32
+	thumbnailQuality   = 85
+	thumbnailMediaType = "image/jpeg"
+)
+
+var coverTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// BlobStore keeps binary content such as cover images out of the database.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, storage.Info, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type BookCoversRepository interface {
+	SetCover(ctx context.Context, id int64, version string) (string, error)
+}
+
+// Covers validates uploaded cover images, generates their thumbnails and
+// keeps all of them in a blob store.
+type Covers struct {
+	books   BookCoversRepository
+	blobs   BlobStore
+	audit   Auditor
+	maxSize int64
+}
+
+func NewCovers(books BookCoversRepository, blobs BlobStore, audit Auditor, maxSize int64) *Covers {
+	return &Covers{
+		books:   books,
+		blobs:   blobs,
+		audit:   audit,
+		maxSize: maxSize,
+	}
+}
+
+// MaxSize is the largest cover upload accepted, in bytes.
+func (s *Covers) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload replaces the cover of the book with the image read from r. The type
+// of the image is sniffed from its content; declaredType, if given, has to
+// agree with it.
+func (s *Covers) Upload(ctx context.Context, bookID int64, r io.Reader, declaredType string) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if int64(len(data)) > s.maxSize {
+		return "", domain.ErrCoverTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := coverTypes[contentType]
+	if !ok || (declaredType != "" && declaredType != contentType) {
+		return "", domain.ErrCoverType
+	}
+
+	// check the dimensions before decoding so a small file can't make us
+	// allocate a huge bitmap
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width < minCoverSide || cfg.Height < minCoverSide || cfg.Width*cfg.Height > maxCoverPixels {
+		return "", domain.ErrInvalidCover
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", domain.ErrInvalidCover
+	}
+
+	version, err := newCoverVersion()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.blobs.Put(ctx, coverKey(bookID, version, domain.CoverOriginal, ext), bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", err
+	}
+
+	for size, width := range domain.CoverWidths {
+		thumb, err := thumbnail(img, width)
+		if err != nil {
+			return "", err
+		}
+
+		if err := s.blobs.Put(ctx, coverKey(bookID, version, size, ""), bytes.NewReader(thumb), int64(len(thumb)), thumbnailMediaType); err != nil {
+			return "", err
+		}
+	}
+
+	previous, err := s.books.SetCover(ctx, bookID, version+"."+ext)
+	if err != nil {
+		s.deleteBlobs(ctx, bookID, version+"."+ext)
+		return "", err
+	}
+
+	s.deleteBlobs(ctx, bookID, previous)
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookCoverSet,
+		Entity:   domain.AuditEntityBook,
+		EntityID: bookID,
+		After:    auditJSON(map[string]string{"version": version}),
+	})
+
+	return domain.CoverPath(bookID, version+"."+ext), nil
+}
+
+func (s *Covers) Delete(ctx context.Context, bookID int64) error {
+	previous, err := s.books.SetCover(ctx, bookID, "")
+	if err != nil {
+		return err
+	}
+
+	if previous == "" {
+		return domain.ErrCoverNotFound
+	}
+
+	s.deleteBlobs(ctx, bookID, previous)
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditBookCoverDrop,
+		Entity:   domain.AuditEntityBook,
+		EntityID: bookID,
+	})
+
+	return nil
+}
+
+// Open returns a size of a cover version as published in the cover URL of a
+// book. The version carries the extension of the original image.
+func (s *Covers) Open(ctx context.Context, bookID int64, version string, size domain.CoverSize) (io.ReadSeekCloser, storage.Info, error) {
+	key, err := coverVersionKey(bookID, version, size)
+	if err != nil {
+		return nil, storage.Info{}, err
+	}
+
+	blob, info, err := s.blobs.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return nil, info, domain.ErrCoverNotFound
+	}
+
+	return blob, info, err
+}
+
+// CleanUpPurged deletes the covers of purged books.
+func (s *Covers) CleanUpPurged(ctx context.Context, books []domain.PurgedBook) {
+	for _, book := range books {
+		s.deleteBlobs(ctx, book.ID, book.CoverVersion)
+	}
+}
+
+func (s *Covers) deleteBlobs(ctx context.Context, bookID int64, version string) {
+	if version == "" {
+		return
+	}
+
+	sizes := []domain.CoverSize{domain.CoverOriginal}
+	for size := range domain.CoverWidths {
+		sizes = append(sizes, size)
+	}
+
+	for _, size := range sizes {
+		key, err := coverVersionKey(bookID, version, size)
+		if err == nil {
+			err = s.blobs.Delete(ctx, key)
+		}
+
+		if err != nil {
+			log.WithField("book_id", bookID).Errorf("failed to delete cover blob: %s", err)
+		}
+	}
+}
+
+// coverVersionKey maps a version such as "3f2a...9c.png" to the blob key of one
+// of its sizes.
+func coverVersionKey(bookID int64, version string, size domain.CoverSize) (string, error) {
+	dot := strings.LastIndexByte(version, '.')
+	if dot <= 0 {
+		return "", domain.ErrCoverNotFound
+	}
+
+	return coverKey(bookID, version[:dot], size, version[dot+1:]), nil
+}
+
+// coverKey is the blob key of a size of a cover. Thumbnails are always JPEGs,
+// the original keeps the extension of its type.
+func coverKey(bookID int64, version string, size domain.CoverSize, ext string) string {
+	if size != domain.CoverOriginal {
+		ext = "jpg"
+	}
+
+	return fmt.Sprintf("covers/%d/%s/%s.%s", bookID, version, size, ext)
+}
+
+func newCoverVersion() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// thumbnail scales img down to the given width, keeping its aspect ratio, and
+// encodes it as a JPEG. Smaller images are never scaled up. Transparent areas
+// become white.
+func thumbnail(img image.Image, width int) ([]byte, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
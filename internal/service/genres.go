@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type GenresRepository interface {
+	Create(ctx context.Context, genre domain.Genre) (int64, error)
+	GetByID(ctx context.Context, id int64) (domain.Genre, error)
+	GetAll(ctx context.Context) ([]domain.Genre, error)
+	Update(ctx context.Context, genre domain.Genre) error
+	Delete(ctx context.Context, id int64) error
+	IsAncestor(ctx context.Context, ancestor, id int64) (bool, error)
+}
+
+type Genres struct {
+	repo GenresRepository
+}
+
+func NewGenres(repo GenresRepository) *Genres {
+	return &Genres{
+		repo: repo,
+	}
+}
+
+func (g *Genres) Create(ctx context.Context, inp domain.GenreInput) (domain.Genre, error) {
+	genre, err := genreFromInput(inp)
+	if err != nil {
+		return genre, err
+	}
+
+	id, err := g.repo.Create(ctx, genre)
+	if err != nil {
+		return genre, err
+	}
+
+	genre.ID = id
+
+	return genre, nil
+}
+
+func (g *Genres) GetByID(ctx context.Context, id int64) (domain.Genre, error) {
+	return g.repo.GetByID(ctx, id)
+}
+
+// GetTree returns the root genres with their subgenres nested in Children.
+func (g *Genres) GetTree(ctx context.Context) ([]domain.Genre, error) {
+	genres, err := g.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int64][]domain.Genre)
+	roots := make([]domain.Genre, 0)
+
+	for _, genre := range genres {
+		if genre.ParentID == nil {
+			roots = append(roots, genre)
+			continue
+		}
+
+		children[*genre.ParentID] = append(children[*genre.ParentID], genre)
+	}
+
+	var build func(genre domain.Genre) domain.Genre
+	build = func(genre domain.Genre) domain.Genre {
+		for _, child := range children[genre.ID] {
+			genre.Children = append(genre.Children, build(child))
+		}
+
+		return genre
+	}
+
+	for i := range roots {
+		roots[i] = build(roots[i])
+	}
+
+	return roots, nil
+}
+
+func (g *Genres) Update(ctx context.Context, id int64, inp domain.GenreInput) error {
+	if inp.ParentID != nil {
+		// a genre can't be moved under itself or one of its own subgenres
+		cycle, err := g.repo.IsAncestor(ctx, id, *inp.ParentID)
+		if err != nil {
+			return err
+		}
+
+		if cycle {
+			return domain.ErrGenreCycle
+		}
+	}
+
+	genre, err := genreFromInput(inp)
+	if err != nil {
+		return err
+	}
+
+	genre.ID = id
+
+	return g.repo.Update(ctx, genre)
+}
+
+func (g *Genres) Delete(ctx context.Context, id int64) error {
+	return g.repo.Delete(ctx, id)
+}
+
+// genreFromInput generates the slug from the name unless one is given. A
+// name like "???" gives none, which would otherwise collide with the next
+// such name as a conflict rather than be reported as bad input.
+func genreFromInput(inp domain.GenreInput) (domain.Genre, error) {
+	slug := inp.Slug
+	if slug == "" {
+		slug = domain.Slugify(inp.Name)
+	}
+
+	genre := domain.Genre{
+		Name:     inp.Name,
+		Slug:     slug,
+		ParentID: inp.ParentID,
+	}
+
+	if slug == "" {
+		return genre, domain.ErrEmptyGenreSlug
+	}
+
+	return genre, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type LoginFailuresRepository interface {
+	Get(ctx context.Context, keys []string) ([]domain.LoginFailures, error)
+	Add(ctx context.Context, key string, at time.Time, window time.Duration) (domain.LoginFailures, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Clear(ctx context.Context, key string) (bool, error)
+}
+
+// LockoutConfig protects sign-in against password guessing. Past DelayAfter
+// failures each attempt has to wait twice as long as the previous one,
+// starting from BaseDelay, and MaxFailures lock the account for LockDuration.
+// An IP address gets IPMaxFailures, as it may be shared by many users.
+// Failures older than Window are forgotten.
+type LockoutConfig struct {
+	DelayAfter    int
+	BaseDelay     time.Duration
+	MaxFailures   int
+	IPMaxFailures int
+	LockDuration  time.Duration
+	Window        time.Duration
+}
+
+// checkLockout returns a *domain.LockedError if the account or the IP address
+// may not attempt to sign in yet.
+func (s *Users) checkLockout(ctx context.Context, keys []string) error {
+	failures, err := s.failuresRepo.Get(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var until time.Time
+	for _, f := range failures {
+		if t := s.retryAt(f); t.After(until) {
+			until = t
+		}
+	}
+
+	if until.After(now) {
+		return &domain.LockedError{Until: until}
+	}
+
+	return nil
+}
+
+// retryAt is when the next attempt may be made after the given failures.
+func (s *Users) retryAt(f domain.LoginFailures) time.Time {
+	var at time.Time
+	if f.LockedUntil != nil {
+		at = *f.LockedUntil
+	}
+
+	if time.Since(f.LastFailedAt) > s.cfg.Lockout.Window || f.Failures < s.cfg.Lockout.DelayAfter {
+		return at
+	}
+
+	delay := s.cfg.Lockout.BaseDelay
+	for i := s.cfg.Lockout.DelayAfter; i < f.Failures && delay < s.cfg.Lockout.LockDuration; i++ {
+		delay *= 2
+	}
+
+	if delay > s.cfg.Lockout.LockDuration {
+		delay = s.cfg.Lockout.LockDuration
+	}
+
+	if t := f.LastFailedAt.Add(delay); t.After(at) {
+		at = t
+	}
+
+	return at
+}
+
+// recordFailure counts a failed attempt against the key, locking it once
+// there have been max failures.
+func (s *Users) recordFailure(ctx context.Context, key string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	f, err := s.failuresRepo.Add(ctx, key, now, s.cfg.Lockout.Window)
+	if err != nil {
+		return err
+	}
+
+	if f.Failures < max {
+		return nil
+	}
+
+	until := now.Add(s.cfg.Lockout.LockDuration)
+	if err := s.failuresRepo.Lock(ctx, key, until); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action: domain.AuditLockedOut,
+		Entity: domain.AuditEntityUser,
+		After: auditJSON(map[string]interface{}{
+			"key":          key,
+			"failures":     f.Failures,
+			"locked_until": until,
+		}),
+	})
+
+	return nil
+}
+
+// Unlock lifts the lockout of an account, an IP address or both.
+func (s *Users) Unlock(ctx context.Context, inp domain.UnlockInput) error {
+	var keys []string
+	if inp.Email != "" {
+		keys = append(keys, domain.LoginFailuresKey("email", inp.Email))
+	}
+
+	if inp.IP != "" {
+		keys = append(keys, domain.LoginFailuresKey("ip", inp.IP))
+	}
+
+	for _, key := range keys {
+		cleared, err := s.failuresRepo.Clear(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if !cleared {
+			continue
+		}
+
+		s.audit.Record(ctx, domain.AuditEntry{
+			ActorID: domain.ActorFromContext(ctx).UserID,
+			Action:  domain.AuditUnlocked,
+			Entity:  domain.AuditEntityUser,
+			After:   auditJSON(map[string]string{"key": key}),
+		})
+	}
+
+	return nil
+}
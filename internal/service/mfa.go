@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/pkg/totp"
+	"github.com/golang-jwt/jwt"
+)
+
+type MFARepository interface {
+	Get(ctx context.Context, userID int64) (domain.MFA, error)
+	Enroll(ctx context.Context, userID int64, secret string, at time.Time) error
+	Confirm(ctx context.Context, userID, counter int64, codeHashes []string, at time.Time) error
+	UseCounter(ctx context.Context, userID, counter int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string, at time.Time) (bool, error)
+	Delete(ctx context.Context, userID int64) error
+}
+
+// MFAConfig sets up TOTP two-factor authentication. Issuer names the service
+// in authenticator apps and ChallengeTTL is how long users have to enter a
+// code after their password. Users with one of RequiredRoles only get the
+// privileges of their role once they have enabled it.
+type MFAConfig struct {
+	Issuer        string
+	ChallengeTTL  time.Duration
+	RequiredRoles []domain.Role
+}
+
+const (
+	mfaAudience       = "mfa"
+	recoveryCodeCount = 10
+	// totpSkew is how many periods a code may be off, for clock drift and
+	// slow typists.
+	totpSkew = 1
+)
+
+// EnrollMFA starts the setup of two-factor authentication for the signed in
+// user, replacing an earlier setup they didn't confirm. It only protects
+// sign-in once confirmed with ConfirmMFA.
+func (s *Users) EnrollMFA(ctx context.Context) (domain.MFAEnrollment, error) {
+	user, err := s.repo.GetByID(ctx, domain.ActorFromContext(ctx).UserID)
+	if err != nil {
+		return domain.MFAEnrollment{}, err
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return domain.MFAEnrollment{}, err
+	}
+
+	if err := s.mfaRepo.Enroll(ctx, user.ID, secret, time.Now()); err != nil {
+		return domain.MFAEnrollment{}, err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditMFAEnrolled,
+		Entity:   domain.AuditEntityUser,
+		EntityID: user.ID,
+	})
+
+	return domain.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA enables two-factor authentication for the signed in user given
+// a code from their authenticator app. It returns the recovery codes, each
+// good for one sign-in without the app, which are only kept hashed.
+func (s *Users) ConfirmMFA(ctx context.Context, inp domain.MFACodeInput) ([]string, error) {
+	userID := domain.ActorFromContext(ctx).UserID
+
+	m, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Enabled() {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	key := mfaFailuresKey(userID)
+	if err := s.checkLockout(ctx, []string{key}); err != nil {
+		return nil, err
+	}
+
+	counter, ok := totp.Validate(m.Secret, inp.Code, time.Now(), totpSkew)
+	if !ok {
+		if err := s.recordFailure(ctx, key, s.cfg.Lockout.MaxFailures); err != nil {
+			return nil, err
+		}
+
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := s.mfaRepo.Confirm(ctx, userID, counter, hashes, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.failuresRepo.Clear(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditMFAEnabled,
+		Entity:   domain.AuditEntityUser,
+		EntityID: userID,
+	})
+
+	return codes, nil
+}
+
+// DisableMFA turns two-factor authentication off for the signed in user,
+// given a code from their authenticator app or a recovery code.
+func (s *Users) DisableMFA(ctx context.Context, inp domain.MFACodeInput) error {
+	userID := domain.ActorFromContext(ctx).UserID
+
+	m, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// an unconfirmed setup never protected anything
+	if m.Enabled() {
+		if err := s.checkMFACode(ctx, m, inp.Code); err != nil {
+			return err
+		}
+	}
+
+	if err := s.mfaRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditMFADisabled,
+		Entity:   domain.AuditEntityUser,
+		EntityID: userID,
+	})
+
+	return nil
+}
+
+// VerifyMFA completes a sign-in that returned a *domain.MFAChallengeError,
+// given its token and a code from the authenticator app or a recovery code.
+func (s *Users) VerifyMFA(ctx context.Context, inp domain.MFAChallengeInput) (string, string, error) {
+	userID, err := s.parseMFAToken(inp.Token)
+	if err != nil {
+		return "", "", domain.ErrInvalidMFAToken
+	}
+
+	m, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFANotEnrolled) {
+			return "", "", domain.ErrInvalidMFAToken
+		}
+
+		return "", "", err
+	}
+
+	// disabled since the challenge was issued
+	if !m.Enabled() {
+		return "", "", domain.ErrInvalidMFAToken
+	}
+
+	if err := s.checkMFACode(ctx, m, inp.Code); err != nil {
+		return "", "", err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user.DisabledAt != nil {
+		return "", "", domain.ErrUserDisabled
+	}
+
+	return s.completeSignIn(ctx, user)
+}
+
+// checkMFACode accepts a current code of the authenticator app, that wasn't
+// used yet, or an unused recovery code. Failures count against a lockout of
+// their own, so that codes can't be guessed.
+func (s *Users) checkMFACode(ctx context.Context, m domain.MFA, code string) error {
+	key := mfaFailuresKey(m.UserID)
+	if err := s.checkLockout(ctx, []string{key}); err != nil {
+		return err
+	}
+
+	ok, recovery, err := s.useMFACode(ctx, m, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		s.audit.Record(ctx, domain.AuditEntry{
+			Action:   domain.AuditMFAFailed,
+			Entity:   domain.AuditEntityUser,
+			EntityID: m.UserID,
+		})
+
+		if err := s.recordFailure(ctx, key, s.cfg.Lockout.MaxFailures); err != nil {
+			return err
+		}
+
+		return domain.ErrInvalidMFACode
+	}
+
+	if recovery {
+		s.audit.Record(ctx, domain.AuditEntry{
+			Action:   domain.AuditRecoveryUsed,
+			Entity:   domain.AuditEntityUser,
+			EntityID: m.UserID,
+		})
+	}
+
+	_, err = s.failuresRepo.Clear(ctx, key)
+
+	return err
+}
+
+func (s *Users) useMFACode(ctx context.Context, m domain.MFA, code string) (ok, recovery bool, err error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		counter, valid := totp.Validate(m.Secret, code, time.Now(), totpSkew)
+		if !valid {
+			return false, false, nil
+		}
+
+		// a code seen by someone looking over the user's shoulder is
+		// worthless once used
+		ok, err := s.mfaRepo.UseCounter(ctx, m.UserID, counter)
+
+		return ok, false, err
+	}
+
+	ok, err = s.mfaRepo.UseRecoveryCode(ctx, m.UserID, hashToken(normalizeRecoveryCode(code)), time.Now())
+
+	return ok, true, err
+}
+
+// mfaRequired tells whether the role may only be used with two-factor
+// authentication enabled.
+func (s *Users) mfaRequired(role domain.Role) bool {
+	for _, r := range s.cfg.MFA.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newMFAChallenge returns the error SignIn answers with when the user still
+// has to enter a code. Its token is a JWT that ParseToken refuses, so that it
+// can't be used as an access token.
+func (s *Users) newMFAChallenge(userID int64) error {
+	expiresAt := time.Now().Add(s.cfg.MFA.ChallengeTTL)
+
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
+		Subject:   strconv.Itoa(int(userID)),
+		Audience:  mfaAudience,
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+
+	token, err := t.SignedString(s.hmacSecret)
+	if err != nil {
+		return err
+	}
+
+	return &domain.MFAChallengeError{Token: token, ExpiresAt: expiresAt}
+}
+
+func (s *Users) parseMFAToken(token string) (int64, error) {
+	var claims jwt.StandardClaims
+
+	if _, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return s.hmacSecret, nil
+	}); err != nil {
+		return 0, err
+	}
+
+	if !claims.VerifyAudience(mfaAudience, true) {
+		return 0, errors.New("invalid audience")
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, errors.New("invalid subject")
+	}
+
+	return int64(id), nil
+}
+
+func mfaFailuresKey(userID int64) string {
+	return domain.LoginFailuresKey("mfa", strconv.FormatInt(userID, 10))
+}
+
+// newRecoveryCode returns a code like "4f2a9-0c3e1", easy to copy by hand.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := hex.EncodeToString(b)
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
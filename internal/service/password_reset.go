@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/pkg/mail"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type PasswordResetsRepository interface {
+	Create(ctx context.Context, reset domain.PasswordReset) error
+	LastSentAt(ctx context.Context, userID int64) (time.Time, error)
+	Use(ctx context.Context, tokenHash string, at time.Time) (domain.PasswordReset, error)
+}
+
+// PasswordResetConfig controls password resets. URL is the address of the
+// page where users pick their new password, which the token is appended to.
+// A user is sent at most one reset every Interval.
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+	Interval time.Duration
+	URL      string
+}
+
+// ForgotPassword sends a password reset link to the email address if it
+// belongs to a user, unless one was sent less than Interval ago. The outcome
+// is the same whether it does or not, and the email is sent in the
+// background so that timing doesn't tell either.
+func (s *Users) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		last, err := s.resetsRepo.LastSentAt(ctx, user.ID)
+		if err == nil && time.Since(last) >= s.cfg.PasswordReset.Interval {
+			err = s.sendPasswordReset(ctx, user)
+		}
+
+		if err != nil {
+			log.WithField("user_id", user.ID).Errorf("failed to send password reset email: %s", err)
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword sets a new password with a token from a reset link. Tokens
+// work once, and using one voids the other links sent to the user. All the
+// user's sessions are revoked, as whoever knew the old password may have
+// signed in with it. The token is only spent if the password is changed.
+func (s *Users) ResetPassword(ctx context.Context, inp domain.ResetPasswordInput) error {
+	password, err := s.hasher.Hash(inp.Password)
+	if err != nil {
+		return err
+	}
+
+	var reset domain.PasswordReset
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if reset, err = s.resetsRepo.Use(ctx, hashToken(inp.Token), time.Now()); err != nil {
+			return err
+		}
+
+		if err := s.repo.SetPassword(ctx, reset.UserID, password); err != nil {
+			return err
+		}
+
+		_, err = s.sessionsRepo.DeleteByUser(ctx, reset.UserID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:  reset.UserID,
+		Action:   domain.AuditPasswordReset,
+		Entity:   domain.AuditEntityUser,
+		EntityID: reset.UserID,
+	})
+
+	return nil
+}
+
+// sendPasswordReset mails a reset link to the user.
+func (s *Users) sendPasswordReset(ctx context.Context, user domain.User) error {
+	token, err := newNonce()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.PasswordReset.TokenTTL)
+
+	if err := s.resetsRepo.Create(ctx, domain.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditResetRequested,
+		Entity:   domain.AuditEntityUser,
+		EntityID: user.ID,
+	})
+
+	link := s.cfg.PasswordReset.URL + "?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nsomeone asked to reset the password of your account. To choose a new one, open the link below:\n\n%s\n\n"+
+			"The link expires on %s. If it wasn't you, you can ignore this email and your password stays the same.\n",
+			user.Name, link, expiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+// hashToken is how reset tokens are stored, so that a leaked table doesn't
+// let anyone reset passwords. Tokens are random, so no salt is needed.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+// journal records the writes of the fakes below; journalTx drops the writes
+// made in a transaction that fails, as the database would.
+type journal struct{ writes []string }
+
+type journalTx struct{ j *journal }
+
+func (t journalTx) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	n := len(t.j.writes)
+	if err := fn(ctx); err != nil {
+		t.j.writes = t.j.writes[:n]
+		return err
+	}
+
+	return nil
+}
+
+type fakeResets struct {
+	PasswordResetsRepository
+	j *journal
+}
+
+func (f fakeResets) Use(ctx context.Context, tokenHash string, at time.Time) (domain.PasswordReset, error) {
+	if tokenHash != hashToken("token") {
+		return domain.PasswordReset{}, domain.ErrInvalidResetToken
+	}
+
+	f.j.writes = append(f.j.writes, "use token")
+	return domain.PasswordReset{UserID: 1}, nil
+}
+
+type fakePasswordUsers struct {
+	UsersRepository
+	j   *journal
+	err error
+}
+
+func (f fakePasswordUsers) SetPassword(ctx context.Context, id int64, password string) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.j.writes = append(f.j.writes, "set password")
+	return nil
+}
+
+type fakeSessions struct {
+	SessionsRepository
+	j *journal
+}
+
+func (f fakeSessions) DeleteByUser(ctx context.Context, userID int64) (int, error) {
+	f.j.writes = append(f.j.writes, "delete sessions")
+	return 1, nil
+}
+
+type fakeHasher struct{}
+
+func (fakeHasher) Hash(password string) (string, error) {
+	return "hashed " + password, nil
+}
+
+func TestResetPassword(t *testing.T) {
+	errDB := errors.New("connection reset")
+
+	tests := []struct {
+		name       string
+		token      string
+		setErr     error
+		wantErr    error
+		wantWrites []string
+	}{
+		{"valid token", "token", nil, nil, []string{"use token", "set password", "delete sessions"}},
+		{"invalid token", "other", nil, domain.ErrInvalidResetToken, nil},
+		{"password not saved", "token", errDB, errDB, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &journal{}
+			audit := &fakeAuditor{}
+
+			s := &Users{
+				repo:         fakePasswordUsers{j: j, err: tt.setErr},
+				sessionsRepo: fakeSessions{j: j},
+				resetsRepo:   fakeResets{j: j},
+				hasher:       fakeHasher{},
+				audit:        audit,
+				tx:           journalTx{j},
+			}
+
+			err := s.ResetPassword(context.Background(), domain.ResetPasswordInput{Token: tt.token, Password: "new password"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResetPassword error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(j.writes) != len(tt.wantWrites) {
+				t.Fatalf("committed %v, want %v", j.writes, tt.wantWrites)
+			}
+
+			for i := range j.writes {
+				if j.writes[i] != tt.wantWrites[i] {
+					t.Fatalf("committed %v, want %v", j.writes, tt.wantWrites)
+				}
+			}
+
+			if wantAudit := tt.wantErr == nil; (len(audit.entries) == 1) != wantAudit {
+				t.Errorf("%d audit entries, want one: %t", len(audit.entries), wantAudit)
+			}
+		})
+	}
+}
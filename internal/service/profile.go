@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Profile returns the signed in user.
+func (s *Users) Profile(ctx context.Context) (domain.User, error) {
+	return s.repo.GetByID(ctx, domain.ActorFromContext(ctx).UserID)
+}
+
+// UpdateProfile changes the name or email address of the signed in user. A
+// new email address is unverified until the link sent to it is opened.
+func (s *Users) UpdateProfile(ctx context.Context, inp domain.UpdateUserInput) (domain.User, error) {
+	userID := domain.ActorFromContext(ctx).UserID
+
+	before, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := s.repo.Update(ctx, userID, inp); err != nil {
+		return domain.User{}, err
+	}
+
+	after, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditUserUpdated,
+		Entity:   domain.AuditEntityUser,
+		EntityID: userID,
+		Before:   auditJSON(map[string]string{"name": before.Name, "email": before.Email}),
+		After:    auditJSON(map[string]string{"name": after.Name, "email": after.Email}),
+	})
+
+	if after.Email != before.Email {
+		if err := s.sendVerification(ctx, after); err != nil {
+			log.WithField("user_id", userID).Errorf("failed to send verification email: %s", err)
+		}
+	}
+
+	return after, nil
+}
+
+// ChangePassword sets a new password for the signed in user and revokes all
+// their sessions. It returns a new pair of tokens, so that only the client
+// making the change stays signed in.
+func (s *Users) ChangePassword(ctx context.Context, inp domain.ChangePasswordInput) (string, string, error) {
+	user, err := s.repo.GetByID(ctx, domain.ActorFromContext(ctx).UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	current, err := s.hasher.Hash(inp.CurrentPassword)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := s.repo.GetByCredentials(ctx, user.Email, current); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return "", "", domain.ErrWrongPassword
+		}
+
+		return "", "", err
+	}
+
+	password, err := s.hasher.Hash(inp.NewPassword)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.repo.SetPassword(ctx, user.ID, password); err != nil {
+		return "", "", err
+	}
+
+	if _, err := s.sessionsRepo.DeleteByUser(ctx, user.ID); err != nil {
+		return "", "", err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditPasswordChanged,
+		Entity:   domain.AuditEntityUser,
+		EntityID: user.ID,
+	})
+
+	return s.generateTokens(ctx, user.ID)
+}
+
+// DeleteAccount signs the user out everywhere and schedules the deletion of
+// their account after the grace period. Signing in again before then cancels
+// it. It returns when the account will be deleted.
+func (s *Users) DeleteAccount(ctx context.Context) (time.Time, error) {
+	userID := domain.ActorFromContext(ctx).UserID
+	deleteAt := time.Now().Add(s.cfg.DeletionGrace)
+
+	if err := s.repo.SetDeleteAt(ctx, userID, &deleteAt); err != nil {
+		return time.Time{}, err
+	}
+
+	if _, err := s.sessionsRepo.DeleteByUser(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditDeleteRequested,
+		Entity:   domain.AuditEntityUser,
+		EntityID: userID,
+		After:    auditJSON(map[string]time.Time{"delete_at": deleteAt}),
+	})
+
+	return deleteAt, nil
+}
+
+// cancelDeletion keeps the account of a user who signed in during the grace
+// period.
+func (s *Users) cancelDeletion(ctx context.Context, user domain.User) error {
+	if err := s.repo.SetDeleteAt(ctx, user.ID, nil); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:  user.ID,
+		Action:   domain.AuditDeleteCancelled,
+		Entity:   domain.AuditEntityUser,
+		EntityID: user.ID,
+		Before:   auditJSON(map[string]*time.Time{"delete_at": user.DeleteAt}),
+	})
+
+	return nil
+}
+
+// AccountCleaner deletes what a service keeps outside the database, such as
+// blobs, for accounts removed for good.
+type AccountCleaner interface {
+	CleanUpPurgedUsers(ctx context.Context, users []domain.PurgedUser)
+}
+
+// PurgeDeleted deletes the accounts whose grace period is over, then lets the
+// cleaners delete what the accounts referred to.
+func (s *Users) PurgeDeleted(ctx context.Context, cleaners ...AccountCleaner) (int, error) {
+	users, err := s.repo.Purge(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, cleaner := range cleaners {
+		cleaner.CleanUpPurgedUsers(ctx, users)
+	}
+
+	for _, user := range users {
+		s.audit.Record(ctx, domain.AuditEntry{
+			Action:   domain.AuditUserPurged,
+			Entity:   domain.AuditEntityUser,
+			EntityID: user.ID,
+		})
+	}
+
+	return len(users), nil
+}
+
+// RunAccountPurger calls PurgeDeleted every interval until ctx is cancelled.
+func (s *Users) RunAccountPurger(ctx context.Context, interval time.Duration, cleaners ...AccountCleaner) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeleted(ctx, cleaners...)
+			if err != nil {
+				log.WithField("job", "account_purger").Error(err)
+				continue
+			}
+
+			if purged > 0 {
+				log.WithField("job", "account_purger").Infof("purged %d accounts", purged)
+			}
+		}
+	}
+}
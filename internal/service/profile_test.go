@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type fakePurgeUsers struct {
+	UsersRepository
+	purged []domain.PurgedUser
+}
+
+func (f fakePurgeUsers) Purge(ctx context.Context, before time.Time) ([]domain.PurgedUser, error) {
+	return f.purged, nil
+}
+
+type fakeFileHashes struct {
+	BookFilesRepository
+	inUse  map[string]bool
+	locked []string
+}
+
+func (f *fakeFileHashes) LockHash(ctx context.Context, sha256 string) error {
+	f.locked = append(f.locked, sha256)
+	return nil
+}
+
+func (f *fakeFileHashes) HashInUse(ctx context.Context, sha256 string) (bool, error) {
+	return f.inUse[sha256], nil
+}
+
+type fakeBlobs struct {
+	BlobStore
+	deleted []string
+}
+
+func (f *fakeBlobs) Delete(ctx context.Context, key string) error {
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func TestPurgeDeletedCleansUpFiles(t *testing.T) {
+	hashes := &fakeFileHashes{inUse: map[string]bool{"shared": true}}
+	blobs := &fakeBlobs{}
+	files := NewBookFiles(hashes, nil, blobs, fakeTx{}, 0)
+	audit := &fakeAuditor{}
+
+	s := &Users{
+		repo: fakePurgeUsers{purged: []domain.PurgedUser{
+			{ID: 1, FileHashes: []string{"own", "shared"}},
+			{ID: 2},
+		}},
+		audit: audit,
+	}
+
+	purged, err := s.PurgeDeleted(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if purged != 2 || len(audit.entries) != 2 {
+		t.Errorf("purged %d users with %d audit entries, want 2 and 2", purged, len(audit.entries))
+	}
+
+	if len(hashes.locked) != 2 {
+		t.Errorf("locked hashes %v, want both hashes of user 1", hashes.locked)
+	}
+
+	if len(blobs.deleted) != 1 || blobs.deleted[0] != fileBlobKey("own") {
+		t.Errorf("deleted blobs %v, want only %s", blobs.deleted, fileBlobKey("own"))
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type ReadingRepository interface {
+	CreateSession(ctx context.Context, session domain.ReadingSession) (int64, error)
+	GetSessions(ctx context.Context, userID, bookID int64) ([]domain.ReadingSession, error)
+	DeleteSession(ctx context.Context, userID, bookID, id int64) error
+	FinishedPerMonth(ctx context.Context, userID int64, from, to time.Time) (map[string]int, error)
+	PagesPerDay(ctx context.Context, userID int64, from, to time.Time) ([]domain.DayPages, error)
+	RatingsGiven(ctx context.Context, userID int64, from, to time.Time) (int, float64, error)
+	SetGoal(ctx context.Context, userID int64, year, books int) error
+	GetGoal(ctx context.Context, userID int64, year int) (int, error)
+}
+
+// Reading records the reading sessions of the current user and computes
+// their statistics.
+type Reading struct {
+	repo  ReadingRepository
+	books BookGetter
+}
+
+func NewReading(repo ReadingRepository, books BookGetter) *Reading {
+	return &Reading{
+		repo:  repo,
+		books: books,
+	}
+}
+
+func (s *Reading) RecordSession(ctx context.Context, bookID int64, inp domain.ReadingSessionInput) (domain.ReadingSession, error) {
+	if _, err := s.books.GetByID(ctx, bookID); err != nil {
+		return domain.ReadingSession{}, err
+	}
+
+	session := domain.ReadingSession{
+		UserID:     domain.ActorFromContext(ctx).UserID,
+		BookID:     bookID,
+		Pages:      inp.Pages,
+		Percent:    inp.Percent,
+		Finished:   inp.FinishesBook(),
+		StartedAt:  inp.StartedAt.UTC(),
+		FinishedAt: inp.FinishedAt.UTC(),
+	}
+
+	id, err := s.repo.CreateSession(ctx, session)
+	if err != nil {
+		return session, err
+	}
+
+	session.ID = id
+
+	return session, nil
+}
+
+func (s *Reading) GetSessions(ctx context.Context, bookID int64) ([]domain.ReadingSession, error) {
+	return s.repo.GetSessions(ctx, domain.ActorFromContext(ctx).UserID, bookID)
+}
+
+func (s *Reading) DeleteSession(ctx context.Context, bookID, id int64) error {
+	return s.repo.DeleteSession(ctx, domain.ActorFromContext(ctx).UserID, bookID, id)
+}
+
+func (s *Reading) SetGoal(ctx context.Context, inp domain.ReadingGoalInput) error {
+	return s.repo.SetGoal(ctx, domain.ActorFromContext(ctx).UserID, inp.Year, inp.Books)
+}
+
+// Stats returns the reading statistics of the current user for a calendar
+// year, the current one if year is 0. Days are counted in UTC.
+func (s *Reading) Stats(ctx context.Context, year int) (domain.ReadingStats, error) {
+	userID := domain.ActorFromContext(ctx).UserID
+
+	now := time.Now().UTC()
+	if year == 0 {
+		year = now.Year()
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	stats := domain.ReadingStats{Year: year}
+
+	finished, err := s.repo.FinishedPerMonth(ctx, userID, from, to)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.FinishedPerMonth = make([]domain.MonthCount, 0, 12)
+	for month := from; month.Before(to); month = month.AddDate(0, 1, 0) {
+		key := fmt.Sprintf("%04d-%02d", month.Year(), month.Month())
+		stats.FinishedPerMonth = append(stats.FinishedPerMonth, domain.MonthCount{Month: key, Books: finished[key]})
+		stats.BooksFinished += finished[key]
+	}
+
+	stats.PagesPerDay, err = s.repo.PagesPerDay(ctx, userID, from, to)
+	if err != nil {
+		return stats, err
+	}
+
+	// the average is taken over the days of the year so far, not only the
+	// days with some reading
+	elapsed := to
+	if now.Before(to) {
+		elapsed = now
+	}
+
+	if days := math.Ceil(elapsed.Sub(from).Hours() / 24); days > 0 {
+		var pages int
+		for _, day := range stats.PagesPerDay {
+			pages += day.Pages
+		}
+
+		stats.AveragePagesPerDay = round2(float64(pages) / days)
+	}
+
+	stats.RatingsGiven, stats.AverageRatingGiven, err = s.repo.RatingsGiven(ctx, userID, from, to)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.AverageRatingGiven = round2(stats.AverageRatingGiven)
+
+	target, err := s.repo.GetGoal(ctx, userID, year)
+	if err != nil {
+		return stats, err
+	}
+
+	if target > 0 {
+		stats.Goal = &domain.GoalProgress{
+			Year:     year,
+			Target:   target,
+			Finished: stats.BooksFinished,
+			Percent:  round2(math.Min(100, float64(stats.BooksFinished)*100/float64(target))),
+		}
+	}
+
+	return stats, nil
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+const (
+	defaultReviewsLimit = 20
+	maxReviewsLimit     = 100
+)
+
+type ReviewsRepository interface {
+	Upsert(ctx context.Context, review domain.Review) (domain.Review, error)
+	Delete(ctx context.Context, bookID, userID int64) error
+	GetByID(ctx context.Context, bookID, id int64) (domain.Review, error)
+	GetByUser(ctx context.Context, bookID, userID int64) (domain.Review, error)
+	List(ctx context.Context, bookID int64, filter domain.ReviewFilter) ([]domain.Review, error)
+	Vote(ctx context.Context, reviewID, userID int64) error
+	Unvote(ctx context.Context, reviewID, userID int64) error
+	Report(ctx context.Context, reviewID, userID int64, reason string) error
+	SetHidden(ctx context.Context, bookID, reviewID int64, hidden bool) error
+}
+
+// BookGetter checks that a book exists and is not in the trash.
+type BookGetter interface {
+	GetByID(ctx context.Context, id int64) (domain.Book, error)
+}
+
+type Reviews struct {
+	repo  ReviewsRepository
+	books BookGetter
+	audit Auditor
+}
+
+func NewReviews(repo ReviewsRepository, books BookGetter, audit Auditor) *Reviews {
+	return &Reviews{
+		repo:  repo,
+		books: books,
+		audit: audit,
+	}
+}
+
+func (s *Reviews) List(ctx context.Context, bookID int64, filter domain.ReviewFilter) ([]domain.Review, error) {
+	if _, err := s.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultReviewsLimit
+	}
+
+	if filter.Limit > maxReviewsLimit {
+		filter.Limit = maxReviewsLimit
+	}
+
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.List(ctx, bookID, filter)
+}
+
+func (s *Reviews) GetMine(ctx context.Context, bookID int64) (domain.Review, error) {
+	return s.repo.GetByUser(ctx, bookID, domain.ActorFromContext(ctx).UserID)
+}
+
+// Save rates the book on behalf of the current user, replacing their previous
+// rating and review text if there was one.
+func (s *Reviews) Save(ctx context.Context, bookID int64, inp domain.ReviewInput) (domain.Review, error) {
+	if _, err := s.books.GetByID(ctx, bookID); err != nil {
+		return domain.Review{}, err
+	}
+
+	return s.repo.Upsert(ctx, domain.Review{
+		BookID:    bookID,
+		UserID:    domain.ActorFromContext(ctx).UserID,
+		Rating:    inp.Rating,
+		Text:      inp.Text,
+		UpdatedAt: time.Now(),
+	})
+}
+
+func (s *Reviews) DeleteMine(ctx context.Context, bookID int64) error {
+	return s.repo.Delete(ctx, bookID, domain.ActorFromContext(ctx).UserID)
+}
+
+func (s *Reviews) Vote(ctx context.Context, bookID, reviewID int64) error {
+	review, err := s.repo.GetByID(ctx, bookID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	userID := domain.ActorFromContext(ctx).UserID
+	if review.UserID == userID {
+		return domain.ErrOwnReviewVote
+	}
+
+	return s.repo.Vote(ctx, reviewID, userID)
+}
+
+func (s *Reviews) Unvote(ctx context.Context, bookID, reviewID int64) error {
+	if _, err := s.repo.GetByID(ctx, bookID, reviewID); err != nil {
+		return err
+	}
+
+	return s.repo.Unvote(ctx, reviewID, domain.ActorFromContext(ctx).UserID)
+}
+
+func (s *Reviews) Report(ctx context.Context, bookID, reviewID int64, inp domain.ReportReviewInput) error {
+	if _, err := s.repo.GetByID(ctx, bookID, reviewID); err != nil {
+		return err
+	}
+
+	return s.repo.Report(ctx, reviewID, domain.ActorFromContext(ctx).UserID, inp.Reason)
+}
+
+// SetHidden is the moderation action for admins. Hidden reviews disappear from
+// listings and their ratings stop counting towards the book's average.
+func (s *Reviews) SetHidden(ctx context.Context, bookID, reviewID int64, hidden bool) error {
+	before, err := s.repo.GetByID(ctx, bookID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetHidden(ctx, bookID, reviewID, hidden); err != nil {
+		return err
+	}
+
+	action := domain.AuditReviewShown
+	if hidden {
+		action = domain.AuditReviewHidden
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   action,
+		Entity:   domain.AuditEntityReview,
+		EntityID: reviewID,
+		Before:   auditJSON(before),
+	})
+
+	return nil
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type ShelvesRepository interface {
+	EnsureStatusShelves(ctx context.Context, userID int64) error
+	Create(ctx context.Context, shelf domain.Shelf) (int64, error)
+	GetByID(ctx context.Context, id int64) (domain.Shelf, error)
+	GetBySlug(ctx context.Context, slug string) (domain.Shelf, error)
+	GetByUser(ctx context.Context, userID int64) ([]domain.Shelf, error)
+	Rename(ctx context.Context, id int64, name string) error
+	Delete(ctx context.Context, id int64) error
+	SetShareSlug(ctx context.Context, id int64, slug string) error
+	GetEntries(ctx context.Context, shelfID int64) ([]domain.ShelfEntry, error)
+	AddBook(ctx context.Context, shelf domain.Shelf, bookID int64) error
+	RemoveBook(ctx context.Context, shelfID, bookID int64) error
+	Reorder(ctx context.Context, shelfID int64, bookIDs []int64) error
+}
+
+// Shelves manages the reading lists of the current user.
+type Shelves struct {
+	repo  ShelvesRepository
+	books BooksFinder
+}
+
+func NewShelves(repo ShelvesRepository, books BooksFinder) *Shelves {
+	return &Shelves{
+		repo:  repo,
+		books: books,
+	}
+}
+
+func (s *Shelves) GetAll(ctx context.Context) ([]domain.Shelf, error) {
+	userID := domain.ActorFromContext(ctx).UserID
+
+	if err := s.repo.EnsureStatusShelves(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByUser(ctx, userID)
+}
+
+func (s *Shelves) Create(ctx context.Context, inp domain.ShelfInput) (domain.Shelf, error) {
+	if domain.IsStatusShelfName(inp.Name) {
+		return domain.Shelf{}, domain.ErrReservedShelfName
+	}
+
+	shelf := domain.Shelf{
+		UserID:    domain.ActorFromContext(ctx).UserID,
+		Name:      inp.Name,
+		Kind:      domain.ShelfCustom,
+		CreatedAt: time.Now(),
+	}
+
+	id, err := s.repo.Create(ctx, shelf)
+	if err != nil {
+		return shelf, err
+	}
+
+	shelf.ID = id
+
+	return shelf, nil
+}
+
+// GetByID returns a shelf of the current user with its books in order.
+func (s *Shelves) GetByID(ctx context.Context, id int64) (domain.Shelf, error) {
+	shelf, err := s.getOwn(ctx, id)
+	if err != nil {
+		return shelf, err
+	}
+
+	return s.withEntries(ctx, shelf)
+}
+
+// GetShared returns a shelf published through its share slug. It is
+// read-only and available to anyone holding the link.
+func (s *Shelves) GetShared(ctx context.Context, slug string) (domain.Shelf, error) {
+	shelf, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return shelf, err
+	}
+
+	shelf, err = s.withEntries(ctx, shelf)
+	shelf.ShareSlug = ""
+
+	return shelf, err
+}
+
+func (s *Shelves) Rename(ctx context.Context, id int64, inp domain.ShelfInput) error {
+	shelf, err := s.getOwn(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if shelf.Kind != domain.ShelfCustom {
+		return domain.ErrStatusShelf
+	}
+
+	if domain.IsStatusShelfName(inp.Name) {
+		return domain.ErrReservedShelfName
+	}
+
+	return s.repo.Rename(ctx, id, inp.Name)
+}
+
+func (s *Shelves) Delete(ctx context.Context, id int64) error {
+	shelf, err := s.getOwn(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if shelf.Kind != domain.ShelfCustom {
+		return domain.ErrStatusShelf
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *Shelves) AddBook(ctx context.Context, id, bookID int64) error {
+	shelf, err := s.getOwn(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	books, err := s.books.GetAll(ctx, domain.BookFilter{IDs: []int64{bookID}})
+	if err != nil {
+		return err
+	}
+
+	if len(books) == 0 {
+		return domain.ErrBookNotFound
+	}
+
+	return s.repo.AddBook(ctx, shelf, bookID)
+}
+
+func (s *Shelves) RemoveBook(ctx context.Context, id, bookID int64) error {
+	if _, err := s.getOwn(ctx, id); err != nil {
+		return err
+	}
+
+	return s.repo.RemoveBook(ctx, id, bookID)
+}
+
+// Reorder sets a new order of the books on the shelf. bookIDs must list every
+// book of the shelf exactly once.
+func (s *Shelves) Reorder(ctx context.Context, id int64, bookIDs []int64) error {
+	if _, err := s.getOwn(ctx, id); err != nil {
+		return err
+	}
+
+	entries, err := s.repo.GetEntries(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) != len(bookIDs) {
+		return domain.ErrShelfOrderMismatch
+	}
+
+	onShelf := make(map[int64]bool, len(entries))
+	for _, entry := range entries {
+		onShelf[entry.BookID] = true
+	}
+
+	for _, bookID := range bookIDs {
+		if !onShelf[bookID] {
+			return domain.ErrShelfOrderMismatch
+		}
+
+		// guards against duplicates in bookIDs
+		delete(onShelf, bookID)
+	}
+
+	return s.repo.Reorder(ctx, id, bookIDs)
+}
+
+// Share publishes the shelf under a random slug and returns it. Sharing an
+// already shared shelf keeps its slug.
+func (s *Shelves) Share(ctx context.Context, id int64) (string, error) {
+	shelf, err := s.getOwn(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if shelf.ShareSlug != "" {
+		return shelf.ShareSlug, nil
+	}
+
+	slug, err := newShareSlug()
+	if err != nil {
+		return "", err
+	}
+
+	return slug, s.repo.SetShareSlug(ctx, id, slug)
+}
+
+func (s *Shelves) Unshare(ctx context.Context, id int64) error {
+	if _, err := s.getOwn(ctx, id); err != nil {
+		return err
+	}
+
+	return s.repo.SetShareSlug(ctx, id, "")
+}
+
+// getOwn loads a shelf, hiding the shelves of other users behind
+// ErrShelfNotFound.
+func (s *Shelves) getOwn(ctx context.Context, id int64) (domain.Shelf, error) {
+	shelf, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return shelf, err
+	}
+
+	if shelf.UserID != domain.ActorFromContext(ctx).UserID {
+		return domain.Shelf{}, domain.ErrShelfNotFound
+	}
+
+	return shelf, nil
+}
+
+func (s *Shelves) withEntries(ctx context.Context, shelf domain.Shelf) (domain.Shelf, error) {
+	entries, err := s.repo.GetEntries(ctx, shelf.ID)
+	if err != nil {
+		return shelf, err
+	}
+
+	ids := make([]int64, len(entries))
+	for i := range entries {
+		ids[i] = entries[i].BookID
+	}
+
+	books, err := s.books.GetAll(ctx, domain.BookFilter{IDs: ids})
+	if err != nil {
+		return shelf, err
+	}
+
+	byID := make(map[int64]domain.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	for i := range entries {
+		if book, ok := byID[entries[i].BookID]; ok {
+			entries[i].Book = &book
+		}
+	}
+
+	shelf.Entries = entries
+
+	return shelf, nil
+}
+
+func newShareSlug() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
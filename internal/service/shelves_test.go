@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type fakeShelves struct {
+	ShelvesRepository
+	shelves map[int64]domain.Shelf
+}
+
+func (f *fakeShelves) Create(ctx context.Context, shelf domain.Shelf) (int64, error) {
+	id := int64(len(f.shelves) + 1)
+	f.shelves[id] = shelf
+	return id, nil
+}
+
+func (f *fakeShelves) GetByID(ctx context.Context, id int64) (domain.Shelf, error) {
+	shelf, ok := f.shelves[id]
+	if !ok {
+		return shelf, domain.ErrShelfNotFound
+	}
+
+	return shelf, nil
+}
+
+func (f *fakeShelves) Rename(ctx context.Context, id int64, name string) error {
+	shelf := f.shelves[id]
+	shelf.Name = name
+	f.shelves[id] = shelf
+	return nil
+}
+
+func TestShelvesReservedNames(t *testing.T) {
+	ctx := domain.WithActor(context.Background(), domain.Actor{UserID: 1})
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"Read", domain.ErrReservedShelfName},
+		{"to read", domain.ErrReservedShelfName},
+		{"Read next", nil},
+	}
+
+	for _, tt := range tests {
+		repo := &fakeShelves{shelves: map[int64]domain.Shelf{
+			1: {ID: 1, UserID: 1, Name: "Favourites", Kind: domain.ShelfCustom},
+		}}
+		shelves := NewShelves(repo, nil)
+
+		if _, err := shelves.Create(ctx, domain.ShelfInput{Name: tt.name}); !errors.Is(err, tt.err) {
+			t.Errorf("Create(%q) error = %v, want %v", tt.name, err, tt.err)
+		}
+
+		if err := shelves.Rename(ctx, 1, domain.ShelfInput{Name: tt.name}); !errors.Is(err, tt.err) {
+			t.Errorf("Rename(%q) error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/crud-app/internal/domain"
 	"github.com/golang-jwt/jwt"
+	log "github.com/sirupsen/logrus"
 )
 
 // PasswordHasher provides hashing logic to securely store passwords.
@@ -18,29 +19,71 @@ type PasswordHasher interface {
 }
 
 type UsersRepository interface {
-	Create(ctx context.Context, user domain.User) error
+	Create(ctx context.Context, user domain.User) (int64, error)
 	GetByCredentials(ctx context.Context, email, password string) (domain.User, error)
+	GetRole(ctx context.Context, id int64) (domain.Role, error)
+	SetRole(ctx context.Context, id int64, role domain.Role) error
+	GetByIDs(ctx context.Context, ids []int64) ([]domain.User, error)
+	GetByEmail(ctx context.Context, email string) (domain.User, error)
+	SetVerified(ctx context.Context, id int64, email string, at time.Time) error
+	SetPassword(ctx context.Context, id int64, password string) error
+	GetByID(ctx context.Context, id int64) (domain.User, error)
+	Update(ctx context.Context, id int64, inp domain.UpdateUserInput) error
+	SetDeleteAt(ctx context.Context, id int64, at *time.Time) error
+	Purge(ctx context.Context, before time.Time) ([]domain.PurgedUser, error)
+	Search(ctx context.Context, filter domain.UserFilter) ([]domain.User, error)
+	SetDisabledAt(ctx context.Context, id int64, at *time.Time) error
 }
 
 type SessionsRepository interface {
 	Create(ctx context.Context, token domain.RefreshSession) error
 	Get(ctx context.Context, token string) (domain.RefreshSession, error)
+	DeleteByUser(ctx context.Context, userID int64) (int, error)
+}
+
+// UsersConfig groups the settings of sign-in and of the emails sent to users.
+type UsersConfig struct {
+	Lockout       LockoutConfig
+	Verification  VerificationConfig
+	PasswordReset PasswordResetConfig
+	MFA           MFAConfig
+	// DeletionGrace is how long accounts are kept after their users asked
+	// for them to be deleted.
+	DeletionGrace time.Duration
 }
 
 type Users struct {
-	repo         UsersRepository
-	sessionsRepo SessionsRepository
-	hasher       PasswordHasher
+	repo              UsersRepository
+	sessionsRepo      SessionsRepository
+	failuresRepo      LoginFailuresRepository
+	verificationsRepo EmailVerificationsRepository
+	resetsRepo        PasswordResetsRepository
+	mfaRepo           MFARepository
+	hasher            PasswordHasher
+	mailer            Mailer
+	audit             Auditor
+	tx                Transactor
+	cfg               UsersConfig
 
 	hmacSecret []byte
 }
 
-func NewUsers(repo UsersRepository, sessionsRepo SessionsRepository, hasher PasswordHasher, secret []byte) *Users {
+func NewUsers(repo UsersRepository, sessionsRepo SessionsRepository, failuresRepo LoginFailuresRepository,
+	verificationsRepo EmailVerificationsRepository, resetsRepo PasswordResetsRepository, mfaRepo MFARepository,
+	hasher PasswordHasher, mailer Mailer, secret []byte, audit Auditor, tx Transactor, cfg UsersConfig) *Users {
 	return &Users{
-		repo:         repo,
-		sessionsRepo: sessionsRepo,
-		hasher:       hasher,
-		hmacSecret:   secret,
+		repo:              repo,
+		sessionsRepo:      sessionsRepo,
+		failuresRepo:      failuresRepo,
+		verificationsRepo: verificationsRepo,
+		resetsRepo:        resetsRepo,
+		mfaRepo:           mfaRepo,
+		hasher:            hasher,
+		mailer:            mailer,
+		audit:             audit,
+		tx:                tx,
+		cfg:               cfg,
+		hmacSecret:        secret,
 	}
 }
 
@@ -54,13 +97,108 @@ func (s *Users) SignUp(ctx context.Context, inp domain.SignUpInput) error {
 		Name:         inp.Name,
 		Email:        inp.Email,
 		Password:     password,
+		Role:         domain.RoleUser,
 		RegisteredAt: time.Now(),
 	}
 
-	return s.repo.Create(ctx, user)
+	if user.ID, err = s.repo.Create(ctx, user); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:   domain.AuditSignUp,
+		Entity:   domain.AuditEntityUser,
+		EntityID: user.ID,
+		After: auditJSON(map[string]string{
+			"name":  user.Name,
+			"email": user.Email,
+		}),
+	})
+
+	// the account exists by now, and the user can ask for another email
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.WithField("user_id", user.ID).Errorf("failed to send verification email: %s", err)
+	}
+
+	return nil
+}
+
+// Create adds a user with the given role on behalf of an operator, as
+// opposed to SignUp where users register themselves. The operator is trusted
+// with the email address, which is taken as verified.
+func (s *Users) Create(ctx context.Context, inp domain.SignUpInput, role domain.Role) (domain.User, error) {
+	if !role.Valid() {
+		return domain.User{}, domain.ErrInvalidRole
+	}
+
+	password, err := s.hasher.Hash(inp.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	now := time.Now()
+
+	user := domain.User{
+		Name:         inp.Name,
+		Email:        inp.Email,
+		Password:     password,
+		Role:         role,
+		RegisteredAt: now,
+		VerifiedAt:   &now,
+	}
+
+	if user.ID, err = s.repo.Create(ctx, user); err != nil {
+		return domain.User{}, err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:  domain.ActorFromContext(ctx).UserID,
+		Action:   domain.AuditUserCreated,
+		Entity:   domain.AuditEntityUser,
+		EntityID: user.ID,
+		After: auditJSON(map[string]string{
+			"name":  user.Name,
+			"email": user.Email,
+			"role":  string(user.Role),
+		}),
+	})
+
+	user.Password = ""
+
+	return user, nil
 }
 
+// SignIn is refused with a *domain.LockedError while the account or the
+// caller's IP address is locked out, see LockoutConfig, with ErrUserDisabled
+// for disabled users and with ErrEmailNotVerified if verification is
+// required. Users having two-factor authentication enabled get a
+// *domain.MFAChallengeError instead of tokens, see VerifyMFA.
 func (s *Users) SignIn(ctx context.Context, inp domain.SignInInput) (string, string, error) {
+	emailKey := domain.LoginFailuresKey("email", inp.Email)
+	keys := []string{emailKey}
+
+	var ipKey string
+	if ip := domain.ActorFromContext(ctx).IP; ip != "" {
+		ipKey = domain.LoginFailuresKey("ip", ip)
+		keys = append(keys, ipKey)
+	}
+
+	if err := s.checkLockout(ctx, keys); err != nil {
+		var locked *domain.LockedError
+		if errors.As(err, &locked) {
+			s.audit.Record(ctx, domain.AuditEntry{
+				Action: domain.AuditSignInBlocked,
+				Entity: domain.AuditEntityUser,
+				After: auditJSON(map[string]interface{}{
+					"email":        inp.Email,
+					"locked_until": locked.Until,
+				}),
+			})
+		}
+
+		return "", "", err
+	}
+
 	password, err := s.hasher.Hash(inp.Password)
 	if err != nil {
 		return "", "", err
@@ -68,31 +206,165 @@ func (s *Users) SignIn(ctx context.Context, inp domain.SignInInput) (string, str
 
 	user, err := s.repo.GetByCredentials(ctx, inp.Email, password)
 	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			s.audit.Record(ctx, domain.AuditEntry{
+				Action: domain.AuditSignInFailed,
+				Entity: domain.AuditEntityUser,
+				After:  auditJSON(map[string]string{"email": inp.Email}),
+			})
+
+			if err := s.recordFailure(ctx, emailKey, s.cfg.Lockout.MaxFailures); err != nil {
+				return "", "", err
+			}
+
+			if ipKey != "" {
+				if err := s.recordFailure(ctx, ipKey, s.cfg.Lockout.IPMaxFailures); err != nil {
+					return "", "", err
+				}
+			}
+		}
+
+		return "", "", err
+	}
+
+	// the IP address keeps its count, or an attacker could reset it by
+	// signing in to an account of their own
+	if _, err := s.failuresRepo.Clear(ctx, emailKey); err != nil {
+		return "", "", err
+	}
+
+	if user.DisabledAt != nil {
+		return "", "", domain.ErrUserDisabled
+	}
+
+	if s.cfg.Verification.RequireVerified && user.VerifiedAt == nil {
+		return "", "", domain.ErrEmailNotVerified
+	}
+
+	m, err := s.mfaRepo.Get(ctx, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrMFANotEnrolled) {
 		return "", "", err
 	}
 
-	return s.generateTokens(ctx, user.ID)
+	if m.Enabled() {
+		return "", "", s.newMFAChallenge(user.ID)
+	}
+
+	return s.completeSignIn(ctx, user)
 }
 
-func (s *Users) ParseToken(ctx context.Context, token string) (int64, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+// completeSignIn issues the tokens of a user who proved who they are.
+func (s *Users) completeSignIn(ctx context.Context, user domain.User) (string, string, error) {
+	if user.DeleteAt != nil {
+		if err := s.cancelDeletion(ctx, user); err != nil {
+			return "", "", err
 		}
+	}
 
-		return s.hmacSecret, nil
+	accessToken, refreshToken, err := s.generateTokens(ctx, user.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:  user.ID,
+		Action:   domain.AuditSignIn,
+		Entity:   domain.AuditEntityUser,
+		EntityID: user.ID,
 	})
+
+	return accessToken, refreshToken, nil
+}
+
+// GetRole returns the current role of the user, so that role changes take
+// effect without waiting for the access token to expire. It fails with
+// ErrMFAEnrollmentRequired for users whose role requires two-factor
+// authentication until they enable it.
+func (s *Users) GetRole(ctx context.Context, userID int64) (domain.Role, error) {
+	role, err := s.repo.GetRole(ctx, userID)
+	if err != nil || !s.mfaRequired(role) {
+		return role, err
+	}
+
+	m, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrMFANotEnrolled) {
+		return "", err
+	}
+
+	if !m.Enabled() {
+		return "", domain.ErrMFAEnrollmentRequired
+	}
+
+	return role, nil
+}
+
+// SetRole changes the role of the user. The change applies to requests made
+// with already issued access tokens too, see GetRole.
+func (s *Users) SetRole(ctx context.Context, userID int64, role domain.Role) error {
+	if !role.Valid() {
+		return domain.ErrInvalidRole
+	}
+
+	before, err := s.repo.GetRole(ctx, userID)
 	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:  domain.ActorFromContext(ctx).UserID,
+		Action:   domain.AuditUserRoleSet,
+		Entity:   domain.AuditEntityUser,
+		EntityID: userID,
+		Before:   auditJSON(map[string]string{"role": string(before)}),
+		After:    auditJSON(map[string]string{"role": string(role)}),
+	})
+
+	return nil
+}
+
+// RevokeSessions signs the user out everywhere by dropping their refresh
+// tokens. Access tokens already issued stay valid until they expire.
+func (s *Users) RevokeSessions(ctx context.Context, userID int64) (int, error) {
+	if _, err := s.repo.GetRole(ctx, userID); err != nil {
 		return 0, err
 	}
 
-	if !t.Valid {
-		return 0, errors.New("invalid token")
+	n, err := s.sessionsRepo.DeleteByUser(ctx, userID)
+	if err != nil {
+		return 0, err
 	}
 
-	claims, ok := t.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, errors.New("invalid claims")
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:  domain.ActorFromContext(ctx).UserID,
+		Action:   domain.AuditSessionsRevoked,
+		Entity:   domain.AuditEntityUser,
+		EntityID: userID,
+	})
+
+	return n, nil
+}
+
+// GetByIDs loads several users at once. Users that don't exist are left out
+// of the result.
+func (s *Users) GetByIDs(ctx context.Context, ids []int64) ([]domain.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return s.repo.GetByIDs(ctx, ids)
+}
+
+// ParseToken returns the user an access token was issued to. Tokens of users
+// disabled or deleted since are refused with ErrUserDisabled and
+// ErrUserNotFound, as they can't be revoked otherwise.
+func (s *Users) ParseToken(ctx context.Context, token string) (int64, error) {
+	claims, err := s.parseAccessToken(token)
+	if err != nil {
+		return 0, err
 	}
 
 	subject, ok := claims["sub"].(string)
@@ -105,7 +377,61 @@ func (s *Users) ParseToken(ctx context.Context, token string) (int64, error) {
 		return 0, errors.New("invalid subject")
 	}
 
-	return int64(id), nil
+	user, err := s.repo.GetByID(ctx, int64(id))
+	if err != nil {
+		return 0, err
+	}
+
+	if user.DisabledAt != nil {
+		return 0, domain.ErrUserDisabled
+	}
+
+	return user.ID, nil
+}
+
+// TokenExpiry returns when an access token stops being valid, so that long
+// lived connections opened with it can be closed at that point.
+func (s *Users) TokenExpiry(ctx context.Context, token string) (time.Time, error) {
+	claims, err := s.parseAccessToken(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, errors.New("invalid expiry")
+	}
+
+	return time.Unix(int64(exp), 0), nil
+}
+
+func (s *Users) parseAccessToken(token string) (jwt.MapClaims, error) {
+	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return s.hmacSecret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := t.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid claims")
+	}
+
+	// MFA challenge tokens are signed with the same secret
+	if _, ok := claims["aud"]; ok {
+		return nil, errors.New("invalid audience")
+	}
+
+	return claims, nil
 }
 
 func (s *Users) generateTokens(ctx context.Context, userId int64) (string, string, error) {
@@ -159,5 +485,17 @@ func (s *Users) RefreshTokens(ctx context.Context, refreshToken string) (string,
 		return "", "", domain.ErrRefreshTokenExpired
 	}
 
-	return s.generateTokens(ctx, session.UserID)
+	accessToken, nextRefreshToken, err := s.generateTokens(ctx, session.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:  session.UserID,
+		Action:   domain.AuditTokensRefresh,
+		Entity:   domain.AuditEntityUser,
+		EntityID: session.UserID,
+	})
+
+	return accessToken, nextRefreshToken, nil
 }
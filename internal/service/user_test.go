@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/golang-jwt/jwt"
+)
+
+type fakeTokenUsers struct {
+	UsersRepository
+	users map[int64]domain.User
+}
+
+func (f fakeTokenUsers) GetByID(ctx context.Context, id int64) (domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func TestParseTokenRejectsDisabledUsers(t *testing.T) {
+	disabledAt := time.Now()
+	s := &Users{
+		hmacSecret: []byte("secret"),
+		repo: fakeTokenUsers{users: map[int64]domain.User{
+			1: {ID: 1},
+			2: {ID: 2, DisabledAt: &disabledAt},
+		}},
+	}
+
+	sign := func(id int64) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
+			Subject:   strconv.Itoa(int(id)),
+			ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		}).SignedString(s.hmacSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		userID int64
+		err    error
+	}{
+		{name: "active", userID: 1},
+		{name: "disabled", userID: 2, err: domain.ErrUserDisabled},
+		{name: "deleted", userID: 3, err: domain.ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := s.ParseToken(context.Background(), sign(tt.userID))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseToken() error = %v, want %v", err, tt.err)
+			}
+
+			if tt.err == nil && id != tt.userID {
+				t.Errorf("ParseToken() = %d, want %d", id, tt.userID)
+			}
+		})
+	}
+}
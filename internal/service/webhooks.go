@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+
+	fanOutBatch   = 100
+	deliveryBatch = 20
+
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+)
+
+type WebhooksRepository interface {
+	Create(ctx context.Context, sub domain.WebhookSubscription) (int64, error)
+	GetByID(ctx context.Context, id int64) (domain.WebhookSubscription, error)
+	GetAll(ctx context.Context) ([]domain.WebhookSubscription, error)
+	Update(ctx context.Context, id int64, inp domain.UpdateWebhookInput) error
+	Delete(ctx context.Context, id int64) error
+
+	FanOut(ctx context.Context, limit int) (int, error)
+	PruneOutbox(ctx context.Context, before time.Time) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	MarkSucceeded(ctx context.Context, id int64, statusCode int) error
+	MarkAttemptFailed(ctx context.Context, id int64, statusCode int, reason string, next *time.Time) error
+
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]domain.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, subscriptionID, id int64) (domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, subscriptionID, id int64) (int64, error)
+}
+
+type WebhooksConfig struct {
+	PollInterval    time.Duration
+	Timeout         time.Duration
+	MaxAttempts     int
+	MinBackoff      time.Duration
+	MaxBackoff      time.Duration
+	OutboxRetention time.Duration
+}
+
+// Webhooks manages webhook subscriptions and delivers the events of the
+// outbox to them.
+type Webhooks struct {
+	repo   WebhooksRepository
+	cfg    WebhooksConfig
+	client *http.Client
+}
+
+func NewWebhooks(repo WebhooksRepository, cfg WebhooksConfig) *Webhooks {
+	return &Webhooks{
+		repo:   repo,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Create subscribes a URL to some events. A secret is generated unless one is
+// given; this is the only time it is returned.
+func (s *Webhooks) Create(ctx context.Context, inp domain.WebhookInput) (domain.WebhookSubscription, error) {
+	sub := domain.WebhookSubscription{
+		URL:       inp.URL,
+		Secret:    inp.Secret,
+		Events:    inp.Events,
+		Active:    inp.Active == nil || *inp.Active,
+		CreatedBy: domain.ActorFromContext(ctx).UserID,
+		CreatedAt: time.Now(),
+	}
+
+	if sub.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return sub, err
+		}
+
+		sub.Secret = hex.EncodeToString(b)
+	}
+
+	id, err := s.repo.Create(ctx, sub)
+	if err != nil {
+		return sub, err
+	}
+
+	sub.ID = id
+
+	return sub, nil
+}
+
+func (s *Webhooks) GetAll(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	subs, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+
+	return subs, nil
+}
+
+func (s *Webhooks) GetByID(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	sub, err := s.repo.GetByID(ctx, id)
+	sub.Secret = ""
+
+	return sub, err
+}
+
+func (s *Webhooks) Update(ctx context.Context, id int64, inp domain.UpdateWebhookInput) error {
+	return s.repo.Update(ctx, id, inp)
+}
+
+func (s *Webhooks) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *Webhooks) Deliveries(ctx context.Context, id int64, limit, offset int) ([]domain.WebhookDelivery, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.repo.ListDeliveries(ctx, id, limit, offset)
+}
+
+func (s *Webhooks) GetDelivery(ctx context.Context, id, deliveryID int64) (domain.WebhookDelivery, error) {
+	return s.repo.GetDelivery(ctx, id, deliveryID)
+}
+
+// Redeliver sends a past delivery again, as a new delivery, whatever the
+// outcome of the original was.
+func (s *Webhooks) Redeliver(ctx context.Context, id, deliveryID int64) (domain.WebhookDelivery, error) {
+	newID, err := s.repo.Redeliver(ctx, id, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	return s.repo.GetDelivery(ctx, id, newID)
+}
+
+// Run fans out outbox events and sends due deliveries every poll interval
+// until ctx is cancelled. Several instances may run at once: work is claimed
+// with row locks.
+func (s *Webhooks) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.dispatch(ctx); err != nil {
+				log.WithField("job", "webhooks").Error(err)
+			}
+
+			if err := s.deliverDue(ctx); err != nil {
+				log.WithField("job", "webhooks").Error(err)
+			}
+
+			if time.Since(lastPrune) > time.Hour {
+				if err := s.repo.PruneOutbox(ctx, time.Now().Add(-s.cfg.OutboxRetention)); err != nil {
+					log.WithField("job", "webhooks").Error(err)
+				}
+
+				lastPrune = time.Now()
+			}
+		}
+	}
+}
+
+func (s *Webhooks) dispatch(ctx context.Context) error {
+	for {
+		dispatched, err := s.repo.FanOut(ctx, fanOutBatch)
+		if err != nil || dispatched < fanOutBatch {
+			return err
+		}
+	}
+}
+
+func (s *Webhooks) deliverDue(ctx context.Context) error {
+	// a claimed delivery is invisible to other workers until its lease ends,
+	// which must outlast the request
+	deliveries, err := s.repo.ClaimDue(ctx, deliveryBatch, 2*s.cfg.Timeout)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+
+		go func(delivery domain.WebhookDelivery) {
+			defer wg.Done()
+			s.deliver(ctx, delivery)
+		}(delivery)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (s *Webhooks) deliver(ctx context.Context, delivery domain.WebhookDelivery) {
+	statusCode, err := s.send(ctx, delivery)
+	if err == nil {
+		err = s.repo.MarkSucceeded(ctx, delivery.ID, statusCode)
+		if err != nil {
+			log.WithField("delivery_id", delivery.ID).Errorf("failed to record webhook delivery: %s", err)
+		}
+
+		return
+	}
+
+	var next *time.Time
+	if attempts := delivery.Attempts + 1; attempts < s.cfg.MaxAttempts {
+		at := time.Now().Add(s.backoff(attempts))
+		next = &at
+	}
+
+	if err := s.repo.MarkAttemptFailed(ctx, delivery.ID, statusCode, err.Error(), next); err != nil {
+		log.WithField("delivery_id", delivery.ID).Errorf("failed to record webhook delivery: %s", err)
+	}
+}
+
+// send posts the payload to the subscription. The signature header holds the
+// HMAC-SHA256, keyed with the subscription secret, of the timestamp header, a
+// dot and the body, so receivers can reject replayed requests.
+func (s *Webhooks) send(ctx context.Context, delivery domain.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "crud-app-webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+SignWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the wait after each failed attempt, up to MaxBackoff, with
+// up to 10% of jitter so that retries of a burst don't all land together.
+func (s *Webhooks) backoff(attempts int) time.Duration {
+	wait := float64(s.cfg.MinBackoff) * math.Pow(2, float64(attempts-1))
+	if wait > float64(s.cfg.MaxBackoff) {
+		wait = float64(s.cfg.MaxBackoff)
+	}
+
+	return time.Duration(wait + wait*0.1*mathrand.Float64())
+}
+
+// SignWebhook computes the hex-encoded signature of a webhook payload.
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
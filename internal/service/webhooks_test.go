@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func TestSignWebhook(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			payload:   `{"id":1,"type":"book.created"}`,
+			want:      "7e2cef90d94ed9f68f6403faa4a0b3731ac2d80c5e8feae5fa63f1448fe449cc",
+		},
+		{
+			secret:    "",
+			timestamp: "0",
+			payload:   "",
+			want:      "b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := SignWebhook(tt.secret, tt.timestamp, []byte(tt.payload)); got != tt.want {
+			t.Errorf("SignWebhook(%q, %q, %q) = %s, want %s", tt.secret, tt.timestamp, tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestWebhooksSendSignsRequest(t *testing.T) {
+	payload := []byte(`{"id":1,"type":"book.created"}`)
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != string(payload) {
+			t.Errorf("body %s, want %s", body, payload)
+		}
+
+		header = r.Header
+	}))
+	defer server.Close()
+
+	s := NewWebhooks(nil, WebhooksConfig{Timeout: time.Second})
+
+	_, err := s.send(context.Background(), domain.WebhookDelivery{
+		ID:        7,
+		EventType: domain.EventBookCreated,
+		Payload:   payload,
+		URL:       server.URL,
+		Secret:    "whsec_test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "sha256=" + SignWebhook("whsec_test", header.Get(TimestampHeader), payload)
+	if got := header.Get(SignatureHeader); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+
+	if got := header.Get("X-Webhook-ID"); got != "7" {
+		t.Errorf("X-Webhook-ID = %q, want 7", got)
+	}
+}
+
+func TestWebhooksBackoff(t *testing.T) {
+	s := NewWebhooks(nil, WebhooksConfig{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	tests := []struct {
+		attempts int
+		min      time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{60, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		// jitter adds up to 10%
+		max := tt.min + tt.min/10
+
+		for i := 0; i < 100; i++ {
+			if got := s.backoff(tt.attempts); got < tt.min || got > max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempts, got, tt.min, max)
+			}
+		}
+	}
+}
+
+type fakeWebhooksRepo struct {
+	WebhooksRepository
+	succeeded []int64
+	failed    map[int64]*time.Time
+}
+
+func (f *fakeWebhooksRepo) MarkSucceeded(ctx context.Context, id int64, statusCode int) error {
+	f.succeeded = append(f.succeeded, id)
+	return nil
+}
+
+func (f *fakeWebhooksRepo) MarkAttemptFailed(ctx context.Context, id int64, statusCode int, reason string, next *time.Time) error {
+	f.failed[id] = next
+	return nil
+}
+
+func TestWebhooksDeliverRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		attempts  int
+		wantRetry bool
+	}{
+		{"first attempt", 0, true},
+		{"before last attempt", 1, true},
+		{"last attempt", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeWebhooksRepo{failed: make(map[int64]*time.Time)}
+			s := NewWebhooks(repo, WebhooksConfig{
+				Timeout:     time.Second,
+				MaxAttempts: 3,
+				MinBackoff:  time.Minute,
+				MaxBackoff:  time.Hour,
+			})
+
+			s.deliver(context.Background(), domain.WebhookDelivery{ID: 1, Attempts: tt.attempts, URL: server.URL})
+
+			next, ok := repo.failed[1]
+			if !ok || len(repo.succeeded) != 0 {
+				t.Fatalf("delivery not recorded as failed")
+			}
+
+			if (next != nil) != tt.wantRetry {
+				t.Errorf("next attempt %v, want a retry: %t", next, tt.wantRetry)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package graphql serves books, their reviews and the current user at
+// /graphql. Resolvers go through the same services as the REST handlers.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRequestSize bounds the JSON body of a request, query and variables
+// included.
+const maxRequestSize = 1 << 20
+
+type Books interface {
+	Create(ctx context.Context, book domain.Book) error
+	GetByID(ctx context.Context, id int64) (domain.Book, error)
+	GetByISBN(ctx context.Context, isbn string) (domain.Book, error)
+	GetAll(ctx context.Context, filter domain.BookFilter) ([]domain.Book, error)
+	Update(ctx context.Context, id int64, inp domain.UpdateBookInput) error
+	Delete(ctx context.Context, id int64) error
+	GetTrash(ctx context.Context) ([]domain.Book, error)
+	Restore(ctx context.Context, id int64) error
+}
+
+type Users interface {
+	ParseToken(ctx context.Context, accessToken string) (int64, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]domain.User, error)
+}
+
+type Reviews interface {
+	List(ctx context.Context, bookID int64, filter domain.ReviewFilter) ([]domain.Review, error)
+}
+
+// Limits caps how expensive a single query may be. See checkLimits for how
+// the complexity is counted.
+type Limits struct {
+	MaxDepth              int
+	MaxComplexity         int
+	MaxIntrospectionDepth int
+}
+
+type Handler struct {
+	booksService   Books
+	usersService   Users
+	reviewsService Reviews
+	limits         Limits
+	schema         graphql.Schema
+}
+
+func NewHandler(books Books, users Users, reviews Reviews, limits Limits) (*Handler, error) {
+	h := &Handler{
+		booksService:   books,
+		usersService:   users,
+		reviewsService: reviews,
+		limits:         limits,
+	}
+
+	schema, err := h.newSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	h.schema = schema
+
+	return h, nil
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP accepts queries both as POST with a JSON body and as GET with
+// query parameters. Mutations are only allowed over POST.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, err := h.authenticate(r)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserDisabled) {
+			writeErrors(w, http.StatusForbidden, gqlerrors.FormatErrors(err))
+			return
+		}
+
+		logError("graphql", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	req, err := readRequest(w, r)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		writeErrors(w, http.StatusBadRequest, validation.Errors)
+		return
+	}
+
+	if r.Method == http.MethodGet && hasMutation(doc) {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrors(w, http.StatusMethodNotAllowed, gqlerrors.FormatErrors(errors.New("mutations must be sent with POST")))
+		return
+	}
+
+	if err := checkLimits(h.schema, doc, req.Variables, h.limits); err != nil {
+		writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, h.newLoaders()),
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// authenticate expects the same Bearer access token as the REST API and puts
+// its user into the request's actor.
+func (h *Handler) authenticate(r *http.Request) (context.Context, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, errors.New("empty auth header")
+	}
+
+	headerParts := strings.Split(header, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" || headerParts[1] == "" {
+		return nil, errors.New("invalid auth header")
+	}
+
+	userId, err := h.usersService.ParseToken(r.Context(), headerParts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	actor := domain.ActorFromContext(r.Context())
+	actor.UserID = userId
+
+	return domain.WithActor(r.Context(), actor), nil
+}
+
+func readRequest(w http.ResponseWriter, r *http.Request) (request, error) {
+	var req request
+
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+
+		if v := query.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, errors.New("variables must be a JSON object")
+			}
+		}
+	} else {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			return req, err
+		}
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, errors.New("request body must be a JSON object")
+		}
+	}
+
+	if req.Query == "" {
+		return req, errors.New("query is empty")
+	}
+
+	return req, nil
+}
+
+func hasMutation(doc *ast.Document) bool {
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok && op.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs []gqlerrors.FormattedError) {
+	writeJSON(w, status, &graphql.Result{Errors: errs})
+}
+
+func writeJSON(w http.ResponseWriter, status int, result *graphql.Result) {
+	response, err := json.Marshal(result)
+	if err != nil {
+		logError("graphql", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(response)
+}
+
+func logError(handler string, err error) {
+	logrus.WithFields(logrus.Fields{
+		"handler": handler,
+	}).Error(err)
+}
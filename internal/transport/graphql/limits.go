@@ -0,0 +1,188 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// listCost is what a list field is assumed to return when it has no
+	// limit argument telling otherwise.
+	listCost = 20
+	// maxListSize is the largest page the services return, whatever limit
+	// is asked for.
+	maxListSize = 100
+)
+
+// checkLimits rejects documents nesting fields deeper than MaxDepth or costing
+// more than MaxComplexity. Every field costs one, and the selection under a
+// list field is counted once per item the list is expected to return.
+// Introspection fields cost nothing, so that tools can load the schema, but
+// their nesting is capped by MaxIntrospectionDepth.
+func checkLimits(schema graphql.Schema, doc *ast.Document, variables map[string]interface{}, limits Limits) error {
+	w := &limitsWalker{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			w.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		root := schema.QueryType()
+		if op.Operation == ast.OperationTypeMutation {
+			root = schema.MutationType()
+		}
+
+		cost, depth := w.selectionSet(op.SelectionSet, root)
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth)
+		}
+
+		if limits.MaxComplexity > 0 && cost > limits.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", cost, limits.MaxComplexity)
+		}
+
+		if limits.MaxIntrospectionDepth > 0 && w.introspectionDepth > limits.MaxIntrospectionDepth {
+			return fmt.Errorf("introspection depth %d exceeds the limit of %d", w.introspectionDepth, limits.MaxIntrospectionDepth)
+		}
+	}
+
+	return nil
+}
+
+type limitsWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// introspectionDepth is the deepest introspection field seen so far,
+	// counting from the field itself.
+	introspectionDepth int
+}
+
+// selectionSet returns the cost and the depth of the selection made on an
+// object of the given type. The document has been validated already, so
+// fragments are known and free of cycles.
+func (w *limitsWalker) selectionSet(set *ast.SelectionSet, parent *graphql.Object) (int, int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+
+	var cost, depth int
+
+	for _, selection := range set.Selections {
+		var c, d int
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			c, d = w.field(s, parent)
+		case *ast.InlineFragment:
+			c, d = w.selectionSet(s.SelectionSet, parent)
+		case *ast.FragmentSpread:
+			if fragment, ok := w.fragments[s.Name.Value]; ok {
+				c, d = w.selectionSet(fragment.SelectionSet, parent)
+			}
+		}
+
+		cost += c
+		depth = max(depth, d)
+	}
+
+	return cost, depth
+}
+
+func (w *limitsWalker) field(field *ast.Field, parent *graphql.Object) (int, int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		w.introspectionDepth = max(w.introspectionDepth, 1+w.depth(field.SelectionSet))
+		return 0, 0
+	}
+
+	def, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		return 1, 1
+	}
+
+	fieldType, isList := unwrapType(def.Type)
+	object, _ := fieldType.(*graphql.Object)
+
+	cost, depth := w.selectionSet(field.SelectionSet, object)
+	if isList {
+		cost *= w.listSize(field)
+	}
+
+	return 1 + cost, 1 + depth
+}
+
+// depth returns how deep set nests fields, without looking at their types.
+// It measures introspection selections, whose types aren't in the schema.
+func (w *limitsWalker) depth(set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+
+	var depth int
+
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			depth = max(depth, 1+w.depth(s.SelectionSet))
+		case *ast.InlineFragment:
+			depth = max(depth, w.depth(s.SelectionSet))
+		case *ast.FragmentSpread:
+			if fragment, ok := w.fragments[s.Name.Value]; ok {
+				depth = max(depth, w.depth(fragment.SelectionSet))
+			}
+		}
+	}
+
+	return depth
+}
+
+// unwrapType strips non-null and list wrappers off t, reporting whether
+// there was a list among them.
+func unwrapType(t graphql.Type) (graphql.Type, bool) {
+	var isList bool
+
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.List:
+			t, isList = wrapped.OfType, true
+		default:
+			return t, isList
+		}
+	}
+}
+
+func (w *limitsWalker) listSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "limit" {
+			continue
+		}
+
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return min(n, maxListSize)
+			}
+		case *ast.Variable:
+			if n, ok := w.variables[v.Name.Value].(float64); ok && n > 0 {
+				return int(min(n, maxListSize))
+			}
+		}
+	}
+
+	return listCost
+}
@@ -0,0 +1,130 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// introspectionQuery is the query GraphiQL and graphql-js tooling load the
+// schema with.
+const introspectionQuery = `
+query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives { name description locations args { ...InputValue } }
+  }
+}
+
+fragment FullType on __Type {
+  kind name description
+  fields(includeDeprecated: true) {
+    name description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name
+    ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } } }
+}`
+
+func TestCheckLimits(t *testing.T) {
+	schema, err := (&Handler{}).newSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limits := Limits{MaxDepth: 3, MaxComplexity: 100, MaxIntrospectionDepth: 15}
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{
+			name:  "list of scalars",
+			query: `{ books { id title } }`,
+		},
+		{
+			name:  "nested within depth",
+			query: `{ book(id: 1) { reviews(limit: 5) { id text } } }`,
+		},
+		{
+			name:    "depth over the limit",
+			query:   `{ me { id } book(id: 1) { ...Reviews } } fragment Reviews on Book { reviews(limit: 1) { user { name } } }`,
+			wantErr: "query depth 4 exceeds the limit of 3",
+		},
+		{
+			name:    "list cost multiplies",
+			query:   `{ books { reviews { id } } }`,
+			wantErr: "query complexity 421 exceeds the limit of 100",
+		},
+		{
+			name:  "limit argument lowers list cost",
+			query: `{ books { reviews(limit: 2) { id } } }`,
+		},
+		{
+			name:  "typename costs nothing",
+			query: `{ books { __typename id } }`,
+		},
+		{
+			name:  "introspection query of tools",
+			query: introspectionQuery,
+		},
+		{
+			name:    "deep introspection",
+			query:   `{ __schema { types { fields { type ` + nestOfType(11) + ` } } } }`,
+			wantErr: "introspection depth 16 exceeds the limit of 15",
+		},
+		{
+			name:    "deep introspection through a fragment",
+			query:   `{ book(id: 1) { title } __type(name: "Book") { ...Deep } } fragment Deep on __Type { fields { type ` + nestOfType(12) + ` } }`,
+			wantErr: "introspection depth 16 exceeds the limit of 15",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = checkLimits(schema, doc, nil, limits)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// nestOfType returns a selection nesting ofType n times down to a name.
+func nestOfType(n int) string {
+	return "{ " + strings.Repeat("ofType { ", n) + "name" + strings.Repeat(" }", n) + " }"
+}
@@ -0,0 +1,98 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long a loader collects keys before running a batch. The
+// executor resolves a whole level of the query before waiting on any of it,
+// so a short window is enough to batch all of its siblings.
+const loaderWait = 2 * time.Millisecond
+
+// loaders batch the lookups of one request. They are created per request so
+// that their caches never outlive it.
+type loaders struct {
+	books *dataloader.Loader[int64, domain.Book]
+	users *dataloader.Loader[int64, domain.User]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (h *Handler) newLoaders() *loaders {
+	return &loaders{
+		books: dataloader.NewBatchedLoader(h.batchBooks, dataloader.WithWait[int64, domain.Book](loaderWait)),
+		users: dataloader.NewBatchedLoader(h.batchUsers, dataloader.WithWait[int64, domain.User](loaderWait)),
+	}
+}
+
+func (h *Handler) batchBooks(ctx context.Context, ids []int64) []*dataloader.Result[domain.Book] {
+	books, err := h.booksService.GetAll(ctx, domain.BookFilter{IDs: ids})
+	if err != nil {
+		return failBatch[domain.Book](len(ids), err)
+	}
+
+	byID := make(map[int64]domain.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	results := make([]*dataloader.Result[domain.Book], len(ids))
+	for i, id := range ids {
+		book, ok := byID[id]
+		if !ok {
+			results[i] = &dataloader.Result[domain.Book]{Error: domain.ErrBookNotFound}
+			continue
+		}
+
+		results[i] = &dataloader.Result[domain.Book]{Data: book}
+	}
+
+	return results
+}
+
+func (h *Handler) batchUsers(ctx context.Context, ids []int64) []*dataloader.Result[domain.User] {
+	users, err := h.usersService.GetByIDs(ctx, ids)
+	if err != nil {
+		return failBatch[domain.User](len(ids), err)
+	}
+
+	byID := make(map[int64]domain.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	results := make([]*dataloader.Result[domain.User], len(ids))
+	for i, id := range ids {
+		user, ok := byID[id]
+		if !ok {
+			results[i] = &dataloader.Result[domain.User]{Error: domain.ErrUserNotFound}
+			continue
+		}
+
+		results[i] = &dataloader.Result[domain.User]{Data: user}
+	}
+
+	return results
+}
+
+func failBatch[V any](n int, err error) []*dataloader.Result[V] {
+	results := make([]*dataloader.Result[V], n)
+	for i := range results {
+		results[i] = &dataloader.Result[V]{Error: err}
+	}
+
+	return results
+}
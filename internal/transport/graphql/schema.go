@@ -0,0 +1,587 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/graphql-go/graphql"
+)
+
+// publicErrors are safe to show to clients as they are. Other resolver errors
+// are logged and replaced by a generic message.
+var publicErrors = []error{
+	domain.ErrBookNotFound,
+	domain.ErrUserNotFound,
+	domain.ErrAuthorNotFound,
+	domain.ErrInvalidAuthorRole,
+	domain.ErrInvalidISBN,
+	domain.ErrISBNMismatch,
+	domain.ErrISBNConflict,
+}
+
+func (h *Handler) newSchema() (graphql.Schema, error) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"email":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"role":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"registeredAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	// Reviewer is the part of a user that is visible to everyone.
+	reviewerType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Reviewer",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	reviewType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Review",
+		Fields: graphql.Fields{
+			"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"rating":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"text":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"helpfulCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"createdAt":    &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"updatedAt":    &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"user": &graphql.Field{
+				Type:    reviewerType,
+				Resolve: h.resolveReviewUser,
+			},
+		},
+	})
+
+	bookAuthorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BookAuthor",
+		Fields: graphql.Fields{
+			"authorId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"role":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	genreType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Genre",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"slug": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	reviewSortEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "ReviewSort",
+		Values: graphql.EnumValueConfigMap{
+			"RECENT":  &graphql.EnumValueConfig{Value: domain.ReviewsRecent},
+			"HELPFUL": &graphql.EnumValueConfig{Value: domain.ReviewsHelpful},
+		},
+	})
+
+	bookType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Book",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"author":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"isbn10":      &graphql.Field{Type: graphql.String, Resolve: optionalString(func(b domain.Book) string { return b.ISBN10 })},
+			"isbn13":      &graphql.Field{Type: graphql.String, Resolve: optionalString(func(b domain.Book) string { return b.ISBN13 })},
+			"publishDate": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"rating":      &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"ratingCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"authors":     &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookAuthorType)))},
+			"genres":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(genreType)))},
+			"tags":        &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"coverUrl":    &graphql.Field{Type: graphql.String, Resolve: optionalString(func(b domain.Book) string { return b.CoverURL })},
+			"deletedAt":   &graphql.Field{Type: graphql.DateTime},
+			"reviews": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(reviewType))),
+				Args: graphql.FieldConfigArgument{
+					"sort":   &graphql.ArgumentConfig{Type: reviewSortEnum, DefaultValue: domain.ReviewsRecent},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: h.resolveBookReviews,
+			},
+		},
+	})
+
+	tagsModeEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "TagsMode",
+		Values: graphql.EnumValueConfigMap{
+			"ANY": &graphql.EnumValueConfig{Value: domain.TagsAny},
+			"ALL": &graphql.EnumValueConfig{Value: domain.TagsAll},
+		},
+	})
+
+	bookFilterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":           &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"author":          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"authorId":        &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"genreId":         &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"tags":            &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"tagsMode":        &graphql.InputObjectFieldConfig{Type: tagsModeEnum},
+			"minRating":       &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"maxRating":       &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"publishedAfter":  &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+			"publishedBefore": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		},
+	})
+
+	bookAuthorInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookAuthorInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"authorId": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"role":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	bookInputFields := func(titleType graphql.Input) graphql.InputObjectConfigFieldMap {
+		return graphql.InputObjectConfigFieldMap{
+			"title":       &graphql.InputObjectFieldConfig{Type: titleType},
+			"author":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"isbn10":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"isbn13":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"publishDate": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+			"authors":     &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(bookAuthorInput))},
+		}
+	}
+
+	createBookInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:   "CreateBookInput",
+		Fields: bookInputFields(graphql.NewNonNull(graphql.String)),
+	})
+
+	updateBookInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:   "UpdateBookInput",
+		Fields: bookInputFields(graphql.String),
+	})
+
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type:    graphql.NewNonNull(userType),
+				Resolve: h.resolveMe,
+			},
+			"book": &graphql.Field{
+				Type:    bookType,
+				Args:    idArgs,
+				Resolve: h.resolveBook,
+			},
+			"bookByIsbn": &graphql.Field{
+				Type: bookType,
+				Args: graphql.FieldConfigArgument{
+					"isbn": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: h.resolveBookByISBN,
+			},
+			"books": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType))),
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: bookFilterInput},
+				},
+				Resolve: h.resolveBooks,
+			},
+			"trash": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType))),
+				Resolve: h.resolveTrash,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createBook": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createBookInput)},
+				},
+				Resolve: h.resolveCreateBook,
+			},
+			"updateBook": &graphql.Field{
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateBookInput)},
+				},
+				Resolve: h.resolveUpdateBook,
+			},
+			"deleteBook": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    idArgs,
+				Resolve: h.resolveDeleteBook,
+			},
+			"restoreBook": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    idArgs,
+				Resolve: h.resolveRestoreBook,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+func (h *Handler) resolveMe(p graphql.ResolveParams) (interface{}, error) {
+	thunk := loadersFromContext(p.Context).users.Load(p.Context, domain.ActorFromContext(p.Context).UserID)
+
+	return func() (interface{}, error) {
+		user, err := thunk()
+		if err != nil {
+			return nil, resolverError("me", err)
+		}
+
+		return user, nil
+	}, nil
+}
+
+func (h *Handler) resolveBook(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return loadBook(p.Context, id), nil
+}
+
+func (h *Handler) resolveBookByISBN(p graphql.ResolveParams) (interface{}, error) {
+	book, err := h.booksService.GetByISBN(p.Context, p.Args["isbn"].(string))
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			return nil, nil
+		}
+
+		return nil, resolverError("bookByIsbn", err)
+	}
+
+	return book, nil
+}
+
+func (h *Handler) resolveBooks(p graphql.ResolveParams) (interface{}, error) {
+	filter, err := bookFilterArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := h.booksService.GetAll(p.Context, filter)
+	if err != nil {
+		return nil, resolverError("books", err)
+	}
+
+	loaders := loadersFromContext(p.Context)
+	for _, book := range books {
+		loaders.books.Prime(p.Context, book.ID, book)
+	}
+
+	return books, nil
+}
+
+func (h *Handler) resolveTrash(p graphql.ResolveParams) (interface{}, error) {
+	books, err := h.booksService.GetTrash(p.Context)
+	if err != nil {
+		return nil, resolverError("trash", err)
+	}
+
+	return books, nil
+}
+
+func (h *Handler) resolveBookReviews(p graphql.ResolveParams) (interface{}, error) {
+	book := p.Source.(domain.Book)
+
+	filter := domain.ReviewFilter{
+		Sort: p.Args["sort"].(domain.ReviewSort),
+	}
+
+	if limit, ok := p.Args["limit"].(int); ok {
+		filter.Limit = limit
+	}
+
+	if offset, ok := p.Args["offset"].(int); ok {
+		filter.Offset = offset
+	}
+
+	reviews, err := h.reviewsService.List(p.Context, book.ID, filter)
+	if err != nil {
+		return nil, resolverError("reviews", err)
+	}
+
+	return reviews, nil
+}
+
+// resolveReviewUser goes through the users loader, so that the authors of all
+// reviews in a response are fetched at once.
+func (h *Handler) resolveReviewUser(p graphql.ResolveParams) (interface{}, error) {
+	review := p.Source.(domain.Review)
+	thunk := loadersFromContext(p.Context).users.Load(p.Context, review.UserID)
+
+	return func() (interface{}, error) {
+		user, err := thunk()
+		if err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) {
+				return nil, nil
+			}
+
+			return nil, resolverError("user", err)
+		}
+
+		return user, nil
+	}, nil
+}
+
+func (h *Handler) resolveCreateBook(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+
+	book := domain.Book{
+		Title: input["title"].(string),
+	}
+
+	book.Author, _ = input["author"].(string)
+	book.ISBN10, _ = input["isbn10"].(string)
+	book.ISBN13, _ = input["isbn13"].(string)
+	book.PublishDate, _ = input["publishDate"].(time.Time)
+
+	if authors, ok := input["authors"].([]interface{}); ok {
+		var err error
+		if book.Authors, err = bookAuthorsArg(authors); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.booksService.Create(p.Context, book); err != nil {
+		return nil, resolverError("createBook", err)
+	}
+
+	return true, nil
+}
+
+func (h *Handler) resolveUpdateBook(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	input := p.Args["input"].(map[string]interface{})
+
+	var inp domain.UpdateBookInput
+	inp.Title = stringField(input, "title")
+	inp.Author = stringField(input, "author")
+	inp.ISBN10 = stringField(input, "isbn10")
+	inp.ISBN13 = stringField(input, "isbn13")
+
+	if date, ok := input["publishDate"].(time.Time); ok {
+		inp.PublishDate = &date
+	}
+
+	if list, ok := input["authors"].([]interface{}); ok {
+		authors, err := bookAuthorsArg(list)
+		if err != nil {
+			return nil, err
+		}
+
+		if authors == nil {
+			authors = []domain.BookAuthor{}
+		}
+
+		inp.Authors = &authors
+	}
+
+	if err := h.booksService.Update(p.Context, id, inp); err != nil {
+		return nil, resolverError("updateBook", err)
+	}
+
+	loadersFromContext(p.Context).books.Clear(p.Context, id)
+
+	return loadBook(p.Context, id), nil
+}
+
+func (h *Handler) resolveDeleteBook(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.booksService.Delete(p.Context, id); err != nil {
+		return nil, resolverError("deleteBook", err)
+	}
+
+	loadersFromContext(p.Context).books.Clear(p.Context, id)
+
+	return true, nil
+}
+
+func (h *Handler) resolveRestoreBook(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.booksService.Restore(p.Context, id); err != nil {
+		return nil, resolverError("restoreBook", err)
+	}
+
+	loadersFromContext(p.Context).books.Clear(p.Context, id)
+
+	return true, nil
+}
+
+// loadBook resolves to the book through the books loader, or to null if
+// there is no such book.
+func loadBook(ctx context.Context, id int64) func() (interface{}, error) {
+	thunk := loadersFromContext(ctx).books.Load(ctx, id)
+
+	return func() (interface{}, error) {
+		book, err := thunk()
+		if err != nil {
+			if errors.Is(err, domain.ErrBookNotFound) {
+				return nil, nil
+			}
+
+			return nil, resolverError("book", err)
+		}
+
+		return book, nil
+	}
+}
+
+func bookFilterArg(args map[string]interface{}) (domain.BookFilter, error) {
+	var filter domain.BookFilter
+
+	input, ok := args["filter"].(map[string]interface{})
+	if !ok {
+		return filter, nil
+	}
+
+	filter.Title, _ = input["title"].(string)
+	filter.Author, _ = input["author"].(string)
+
+	if _, ok := input["authorId"]; ok {
+		authorID, err := idArg(input, "authorId")
+		if err != nil {
+			return filter, err
+		}
+
+		filter.AuthorID = &authorID
+	}
+
+	if _, ok := input["genreId"]; ok {
+		genreID, err := idArg(input, "genreId")
+		if err != nil {
+			return filter, err
+		}
+
+		filter.GenreID = &genreID
+	}
+
+	if tags, ok := input["tags"].([]interface{}); ok {
+		for _, tag := range tags {
+			if tag := domain.NormalizeTag(tag.(string)); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+
+		filter.TagsMode = domain.TagsAny
+		if mode, ok := input["tagsMode"].(domain.TagsMode); ok {
+			filter.TagsMode = mode
+		}
+	}
+
+	if rating, ok := input["minRating"].(int); ok {
+		filter.MinRating = &rating
+	}
+
+	if rating, ok := input["maxRating"].(int); ok {
+		filter.MaxRating = &rating
+	}
+
+	if date, ok := input["publishedAfter"].(time.Time); ok {
+		filter.PublishedAfter = &date
+	}
+
+	if date, ok := input["publishedBefore"].(time.Time); ok {
+		filter.PublishedBefore = &date
+	}
+
+	return filter, nil
+}
+
+func bookAuthorsArg(list []interface{}) ([]domain.BookAuthor, error) {
+	var authors []domain.BookAuthor
+
+	for _, item := range list {
+		input := item.(map[string]interface{})
+
+		authorID, err := idArg(input, "authorId")
+		if err != nil {
+			return nil, err
+		}
+
+		authors = append(authors, domain.BookAuthor{
+			AuthorID: authorID,
+			Role:     domain.AuthorRole(input["role"].(string)),
+		})
+	}
+
+	return authors, nil
+}
+
+func idArg(args map[string]interface{}, name string) (int64, error) {
+	v, _ := args[name].(string)
+
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+
+	return id, nil
+}
+
+func stringField(input map[string]interface{}, name string) *string {
+	if v, ok := input[name].(string); ok {
+		return &v
+	}
+
+	return nil
+}
+
+// optionalString resolves empty strings of a book to null.
+func optionalString(field func(domain.Book) string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if v := field(p.Source.(domain.Book)); v != "" {
+			return v, nil
+		}
+
+		return nil, nil
+	}
+}
+
+func resolverError(field string, err error) error {
+	for _, public := range publicErrors {
+		if errors.Is(err, public) {
+			return err
+		}
+	}
+
+	logError(field, err)
+
+	return errors.New("internal error")
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/internal/transport/rest"
+	crudv1 "github.com/crud-app/pkg/api/crud/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type authServer struct {
+	crudv1.UnimplementedAuthServiceServer
+
+	usersService rest.User
+}
+
+func (s *authServer) SignUp(ctx context.Context, req *crudv1.SignUpRequest) (*emptypb.Empty, error) {
+	inp := domain.SignUpInput{
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+
+	if err := inp.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.usersService.SignUp(ctx, inp); err != nil {
+		return nil, statusError("SignUp", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *authServer) SignIn(ctx context.Context, req *crudv1.SignInRequest) (*crudv1.TokenPair, error) {
+	inp := domain.SignInInput{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+
+	if err := inp.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	accessToken, refreshToken, err := s.usersService.SignIn(ctx, inp)
+	if err != nil {
+		var challenge *domain.MFAChallengeError
+		if errors.As(err, &challenge) {
+			return &crudv1.TokenPair{MfaToken: challenge.Token}, nil
+		}
+
+		return nil, statusError("SignIn", err)
+	}
+
+	return &crudv1.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *authServer) VerifyMFA(ctx context.Context, req *crudv1.VerifyMFARequest) (*crudv1.TokenPair, error) {
+	inp := domain.MFAChallengeInput{
+		Token: req.GetMfaToken(),
+		Code:  req.GetCode(),
+	}
+
+	if err := inp.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	accessToken, refreshToken, err := s.usersService.VerifyMFA(ctx, inp)
+	if err != nil {
+		return nil, statusError("VerifyMFA", err)
+	}
+
+	return &crudv1.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *authServer) Refresh(ctx context.Context, req *crudv1.RefreshRequest) (*crudv1.TokenPair, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is empty")
+	}
+
+	accessToken, refreshToken, err := s.usersService.RefreshTokens(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, statusError("Refresh", err)
+	}
+
+	return &crudv1.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
@@ -0,0 +1,239 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/internal/transport/rest"
+	crudv1 "github.com/crud-app/pkg/api/crud/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type bookServer struct {
+	crudv1.UnimplementedBookServiceServer
+
+	booksService rest.Books
+}
+
+func (s *bookServer) CreateBook(ctx context.Context, req *crudv1.CreateBookRequest) (*emptypb.Empty, error) {
+	book := domain.Book{
+		Title:   req.GetTitle(),
+		Author:  req.GetAuthor(),
+		ISBN10:  req.GetIsbn10(),
+		ISBN13:  req.GetIsbn13(),
+		Authors: fromProtoAuthors(req.GetAuthors()),
+	}
+
+	if req.PublishDate != nil {
+		book.PublishDate = req.PublishDate.AsTime()
+	}
+
+	if err := s.booksService.Create(ctx, book); err != nil {
+		return nil, statusError("CreateBook", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *bookServer) GetBook(ctx context.Context, req *crudv1.GetBookRequest) (*crudv1.Book, error) {
+	book, err := s.booksService.GetByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError("GetBook", err)
+	}
+
+	return toProtoBook(book), nil
+}
+
+func (s *bookServer) GetBookByISBN(ctx context.Context, req *crudv1.GetBookByISBNRequest) (*crudv1.Book, error) {
+	book, err := s.booksService.GetByISBN(ctx, req.GetIsbn())
+	if err != nil {
+		return nil, statusError("GetBookByISBN", err)
+	}
+
+	return toProtoBook(book), nil
+}
+
+func (s *bookServer) ListBooks(ctx context.Context, req *crudv1.ListBooksRequest) (*crudv1.ListBooksResponse, error) {
+	filter, err := fromProtoFilter(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	books, err := s.booksService.GetAll(ctx, filter)
+	if err != nil {
+		return nil, statusError("ListBooks", err)
+	}
+
+	return toProtoBooks(books), nil
+}
+
+func (s *bookServer) UpdateBook(ctx context.Context, req *crudv1.UpdateBookRequest) (*emptypb.Empty, error) {
+	inp := domain.UpdateBookInput{
+		Title:  req.Title,
+		Author: req.Author,
+		ISBN10: req.Isbn10,
+		ISBN13: req.Isbn13,
+	}
+
+	if req.PublishDate != nil {
+		date := req.PublishDate.AsTime()
+		inp.PublishDate = &date
+	}
+
+	if req.Authors != nil {
+		authors := fromProtoAuthors(req.Authors.GetAuthors())
+		if authors == nil {
+			authors = []domain.BookAuthor{}
+		}
+
+		inp.Authors = &authors
+	}
+
+	if err := s.booksService.Update(ctx, req.GetId(), inp); err != nil {
+		return nil, statusError("UpdateBook", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *bookServer) DeleteBook(ctx context.Context, req *crudv1.DeleteBookRequest) (*emptypb.Empty, error) {
+	if err := s.booksService.Delete(ctx, req.GetId()); err != nil {
+		return nil, statusError("DeleteBook", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *bookServer) ListTrash(ctx context.Context, _ *emptypb.Empty) (*crudv1.ListBooksResponse, error) {
+	books, err := s.booksService.GetTrash(ctx)
+	if err != nil {
+		return nil, statusError("ListTrash", err)
+	}
+
+	return toProtoBooks(books), nil
+}
+
+func (s *bookServer) RestoreBook(ctx context.Context, req *crudv1.RestoreBookRequest) (*emptypb.Empty, error) {
+	if err := s.booksService.Restore(ctx, req.GetId()); err != nil {
+		return nil, statusError("RestoreBook", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func fromProtoFilter(req *crudv1.ListBooksRequest) (domain.BookFilter, error) {
+	filter := domain.BookFilter{
+		Title:    req.GetTitle(),
+		Author:   req.GetAuthor(),
+		AuthorID: req.AuthorId,
+		GenreID:  req.GenreId,
+	}
+
+	for _, tag := range req.GetTags() {
+		if tag = domain.NormalizeTag(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	filter.TagsMode = domain.TagsMode(req.GetTagsMode())
+	switch filter.TagsMode {
+	case "":
+		filter.TagsMode = domain.TagsAny
+	case domain.TagsAny, domain.TagsAll:
+	default:
+		return filter, fmt.Errorf("invalid tags_mode %q", filter.TagsMode)
+	}
+
+	if req.MinRating != nil {
+		rating := int(req.GetMinRating())
+		filter.MinRating = &rating
+	}
+
+	if req.MaxRating != nil {
+		rating := int(req.GetMaxRating())
+		filter.MaxRating = &rating
+	}
+
+	if req.PublishedAfter != nil {
+		date := req.PublishedAfter.AsTime()
+		filter.PublishedAfter = &date
+	}
+
+	if req.PublishedBefore != nil {
+		date := req.PublishedBefore.AsTime()
+		filter.PublishedBefore = &date
+	}
+
+	return filter, nil
+}
+
+func fromProtoAuthors(authors []*crudv1.BookAuthor) []domain.BookAuthor {
+	if len(authors) == 0 {
+		return nil
+	}
+
+	result := make([]domain.BookAuthor, 0, len(authors))
+	for _, a := range authors {
+		result = append(result, domain.BookAuthor{
+			AuthorID: a.GetAuthorId(),
+			Name:     a.GetName(),
+			Role:     domain.AuthorRole(a.GetRole()),
+		})
+	}
+
+	return result
+}
+
+func toProtoBooks(books []domain.Book) *crudv1.ListBooksResponse {
+	resp := &crudv1.ListBooksResponse{
+		Books: make([]*crudv1.Book, 0, len(books)),
+	}
+
+	for _, book := range books {
+		resp.Books = append(resp.Books, toProtoBook(book))
+	}
+
+	return resp
+}
+
+func toProtoBook(book domain.Book) *crudv1.Book {
+	pb := &crudv1.Book{
+		Id:          book.ID,
+		Title:       book.Title,
+		Author:      book.Author,
+		Isbn10:      book.ISBN10,
+		Isbn13:      book.ISBN13,
+		PublishDate: timestamppb.New(book.PublishDate),
+		Rating:      book.Rating,
+		RatingCount: int32(book.RatingCount),
+		Tags:        book.Tags,
+		CoverUrl:    book.CoverURL,
+	}
+
+	if book.DeletedAt != nil {
+		pb.DeletedAt = timestamppb.New(*book.DeletedAt)
+	}
+
+	for _, a := range book.Authors {
+		pb.Authors = append(pb.Authors, &crudv1.BookAuthor{
+			AuthorId: a.AuthorID,
+			Name:     a.Name,
+			Role:     string(a.Role),
+		})
+	}
+
+	for _, g := range book.Genres {
+		pb.Genres = append(pb.Genres, &crudv1.Genre{
+			Id:   g.ID,
+			Name: g.Name,
+			Slug: g.Slug,
+		})
+	}
+
+	return pb
+}
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func logError(method string, err error) {
+	logrus.WithFields(logrus.Fields{
+		"method": method,
+	}).Error(err)
+}
+
+// statusError maps domain errors to gRPC status codes. Anything not known
+// to be the caller's fault is logged and reported as Internal, without its
+// message.
+func statusError(method string, err error) error {
+	var code codes.Code
+
+	switch {
+	case errors.Is(err, domain.ErrBookNotFound):
+		code = codes.NotFound
+	case errors.Is(err, domain.ErrAuthorNotFound),
+		errors.Is(err, domain.ErrInvalidAuthorRole),
+		errors.Is(err, domain.ErrInvalidISBN),
+		errors.Is(err, domain.ErrISBNMismatch):
+		code = codes.InvalidArgument
+	case errors.Is(err, domain.ErrISBNConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, domain.ErrUserNotFound),
+		errors.Is(err, domain.ErrRefreshTokenExpired),
+		errors.Is(err, domain.ErrInvalidMFAToken),
+		errors.Is(err, domain.ErrInvalidMFACode):
+		code = codes.Unauthenticated
+	case errors.Is(err, domain.ErrAccountLocked):
+		code = codes.ResourceExhausted
+	case errors.Is(err, domain.ErrEmailNotVerified),
+		errors.Is(err, domain.ErrUserDisabled),
+		errors.Is(err, domain.ErrMFAEnrollmentRequired):
+		code = codes.PermissionDenied
+	default:
+		logError(method, err)
+		return status.Error(codes.Internal, "internal error")
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/crud-app/internal/domain"
+	crudv1 "github.com/crud-app/pkg/api/crud/v1"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDKey = "x-request-id"
+
+// publicMethods can be called without an access token.
+var publicMethods = map[string]bool{
+	crudv1.AuthService_SignUp_FullMethodName:    true,
+	crudv1.AuthService_SignIn_FullMethodName:    true,
+	crudv1.AuthService_Refresh_FullMethodName:   true,
+	crudv1.AuthService_VerifyMFA_FullMethodName: true,
+}
+
+// requestMetaInterceptor is the gRPC counterpart of the REST request meta
+// middleware: it puts the caller's request ID and network details into the
+// context, and echoes the request ID back in the response header.
+func (h *Handler) requestMetaInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstValue(md, requestIDKey)
+	if requestID == "" || len(requestID) > 64 {
+		requestID = newRequestID()
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	ctx = domain.WithActor(ctx, domain.Actor{
+		IP:        h.clientIP(ctx, md),
+		UserAgent: firstValue(md, "user-agent"),
+		RequestID: requestID,
+	})
+
+	return handler(ctx, req)
+}
+
+func loggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+
+	log.WithFields(log.Fields{
+		"method":     info.FullMethod,
+		"code":       status.Code(err).String(),
+		"request_id": domain.ActorFromContext(ctx).RequestID,
+	}).Info()
+
+	return resp, err
+}
+
+// authInterceptor requires a valid access token in the "authorization"
+// metadata for every method but the public ones.
+func (h *Handler) authInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	token, err := getTokenFromMetadata(md)
+	if err != nil {
+		logError("authInterceptor", err)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	userId, err := h.usersService.ParseToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		logError("authInterceptor", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid access token")
+	}
+
+	actor := domain.ActorFromContext(ctx)
+	actor.UserID = userId
+
+	return handler(domain.WithActor(ctx, actor), req)
+}
+
+func getTokenFromMetadata(md metadata.MD) (string, error) {
+	header := firstValue(md, "authorization")
+	if header == "" {
+		return "", errors.New("empty auth metadata")
+	}
+
+	headerParts := strings.Split(header, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return "", errors.New("invalid auth metadata")
+	}
+
+	if len(headerParts[1]) == 0 {
+		return "", errors.New("token is empty")
+	}
+
+	return headerParts[1], nil
+}
+
+// clientIP reads X-Forwarded-For from the metadata of trusted proxies only,
+// like the REST transport does.
+func (h *Handler) clientIP(ctx context.Context, md metadata.MD) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	return h.proxies.ClientIP(p.Addr.String(), md.Get("x-forwarded-for"))
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+	crudv1 "github.com/crud-app/pkg/api/crud/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimitGroups maps the services to the REST route groups whose limits
+// they share. The keys are the same too, so that a client can't double its
+// allowance by switching transports.
+var rateLimitGroups = map[string]string{
+	crudv1.AuthService_ServiceDesc.ServiceName: "auth",
+	crudv1.BookService_ServiceDesc.ServiceName: "books",
+}
+
+// rateLimitInterceptor is the gRPC counterpart of the REST rate limits. It
+// must come after authInterceptor, so that callers are limited by user when
+// signed in.
+func (h *Handler) rateLimitInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	if h.limits.Store == nil {
+		return handler(ctx, req)
+	}
+
+	service := strings.TrimPrefix(info.FullMethod, "/")
+	service, _, _ = strings.Cut(service, "/")
+
+	group, ok := rateLimitGroups[service]
+	if !ok {
+		group = "default"
+	}
+
+	limit, ok := h.limits.Groups[group]
+	if !ok {
+		limit, ok = h.limits.Groups["default"]
+	}
+
+	if !ok || limit.Rate <= 0 || limit.Burst <= 0 {
+		return handler(ctx, req)
+	}
+
+	actor := domain.ActorFromContext(ctx)
+
+	key := group + ":ip:" + actor.IP
+	if actor.UserID != 0 {
+		key = group + ":user:" + strconv.FormatInt(actor.UserID, 10)
+	}
+
+	res, err := h.limits.Store.Take(ctx, key, limit)
+	if err != nil {
+		// an unavailable store shouldn't take the API down with it
+		logError("rateLimitInterceptor", err)
+		return handler(ctx, req)
+	}
+
+	if !res.Allowed {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(ceilSeconds(res.RetryAfter))))
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	return handler(ctx, req)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
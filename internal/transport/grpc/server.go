@@ -0,0 +1,45 @@
+// Package grpc serves the books and auth parts of the API over gRPC. It is a
+// thin transport over the same services the REST handlers use.
+package grpc
+
+import (
+	"github.com/crud-app/internal/transport/rest"
+	crudv1 "github.com/crud-app/pkg/api/crud/v1"
+	"github.com/crud-app/pkg/realip"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+type Handler struct {
+	booksService rest.Books
+	usersService rest.User
+	limits       rest.RateLimits
+	proxies      realip.Proxies
+}
+
+// NewHandler takes the same rate limits as the REST handler, MaxInFlight
+// aside.
+func NewHandler(books rest.Books, users rest.User, limits rest.RateLimits, proxies realip.Proxies) *Handler {
+	return &Handler{
+		booksService: books,
+		usersService: users,
+		limits:       limits,
+		proxies:      proxies,
+	}
+}
+
+// InitServer builds a gRPC server with the API services and server reflection
+// registered on it.
+func (h *Handler) InitServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(h.requestMetaInterceptor, loggingInterceptor, h.authInterceptor,
+			h.rateLimitInterceptor),
+	)
+
+	crudv1.RegisterAuthServiceServer(srv, &authServer{usersService: h.usersService})
+	crudv1.RegisterBookServiceServer(srv, &bookServer{booksService: h.booksService})
+	reflection.Register(srv)
+
+	return srv
+}
@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func (h *Handler) unlock(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("unlock", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.UnlockInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("unlock", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("unlock", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.Unlock(r.Context(), inp); err != nil {
+		logError("unlock", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) searchUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPageFromRequest(r)
+	if err != nil {
+		logError("searchUsers", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	users, err := h.usersService.SearchUsers(r.Context(), domain.UserFilter{
+		Query:  r.URL.Query().Get("q"),
+		Role:   domain.Role(r.URL.Query().Get("role")),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRole) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		logError("searchUsers", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "searchUsers", http.StatusOK, users)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getUser", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersService.GetUser(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getUser", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getUser", http.StatusOK, user)
+}
+
+func (h *Handler) disableUser(w http.ResponseWriter, r *http.Request) {
+	h.userAction(w, r, "disableUser", h.usersService.DisableUser)
+}
+
+func (h *Handler) enableUser(w http.ResponseWriter, r *http.Request) {
+	h.userAction(w, r, "enableUser", h.usersService.EnableUser)
+}
+
+func (h *Handler) forcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	h.userAction(w, r, "forcePasswordReset", h.usersService.ForcePasswordReset)
+}
+
+// userAction runs an action that takes nothing but the ID of the user.
+func (h *Handler) userAction(w http.ResponseWriter, r *http.Request, handler string,
+	action func(ctx context.Context, id int64) error) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := action(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError(handler, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) setUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("setUserRole", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("setUserRole", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.SetRoleInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("setUserRole", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("setUserRole", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.SetRole(r.Context(), id, inp.Role); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrInvalidRole):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			logError("setUserRole", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) revokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("revokeUserSessions", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := h.usersService.RevokeSessions(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("revokeUserSessions", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "revokeUserSessions", http.StatusOK, map[string]int{"revoked": revoked})
+}
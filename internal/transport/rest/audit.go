@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func (h *Handler) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	filter, err := getAuditFilterFromRequest(r)
+	if err != nil {
+		logError("getAuditLog", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.auditService.List(r.Context(), filter)
+	if err != nil {
+		logError("getAuditLog", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(entries)
+	if err != nil {
+		logError("getAuditLog", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func getAuditFilterFromRequest(r *http.Request) (domain.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		Entity: query.Get("entity"),
+		Action: domain.AuditAction(query.Get("action")),
+	}
+
+	if v := query.Get("actor_id"); v != "" {
+		actorID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid actor_id: %w", err)
+		}
+
+		filter.ActorID = &actorID
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+
+		filter.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+
+		filter.To = &to
+	}
+
+	limit, offset, err := getPageFromRequest(r)
+	if err != nil {
+		return filter, err
+	}
+
+	filter.Limit = limit
+	filter.Offset = offset
+
+	return filter, nil
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/crud-app/internal/domain"
 )
@@ -70,6 +72,31 @@ func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, domain.ErrEmailNotVerified) || errors.Is(err, domain.ErrUserDisabled) {
+			writeJSON(w, "signIn", http.StatusForbidden, map[string]string{"error": err.Error()})
+			return
+		}
+
+		// not a failure, the client goes on with verifyMFA
+		var challenge *domain.MFAChallengeError
+		if errors.As(err, &challenge) {
+			writeJSON(w, "signIn", http.StatusOK, map[string]interface{}{
+				"mfa_token":  challenge.Token,
+				"expires_at": challenge.ExpiresAt,
+			})
+			return
+		}
+
+		var locked *domain.LockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(time.Until(locked.Until))))
+			writeJSON(w, "signIn", http.StatusTooManyRequests, map[string]interface{}{
+				"error":        err.Error(),
+				"locked_until": locked.Until,
+			})
+			return
+		}
+
 		logError("signIn", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -115,11 +142,66 @@ func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token='%s'; HttpOnly", refreshToken))
+	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
 	w.Header().Add("Content-Type", "application/json")
 	w.Write(response)
 }
 
+func (h *Handler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.VerifyEmail(r.Context(), token); err != nil {
+		if errors.Is(err, domain.ErrInvalidVerificationToken) {
+			writeJSON(w, "verifyEmail", http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		logError("verifyEmail", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "verifyEmail", http.StatusOK, map[string]bool{"verified": true})
+}
+
+// resendVerification answers the same whether or not an email was sent, so
+// that it can't be used to find out which addresses have accounts.
+func (h *Handler) resendVerification(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ResendVerificationInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.usersService.ResendVerification(r.Context(), inp.Email)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) &&
+		!errors.Is(err, domain.ErrEmailAlreadyVerified) && !errors.Is(err, domain.ErrVerificationThrottled) {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
 func handleNotFoundError(w http.ResponseWriter, err error) {
 	response, _ := json.Marshal(map[string]string{
 		"error": err.Error(),
@@ -0,0 +1,221 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func (h *Handler) createAuthor(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("createAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.AuthorInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("createAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("createAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	author, err := h.authorsService.Create(r.Context(), inp)
+	if err != nil {
+		if errors.Is(err, domain.ErrAuthorExists) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		logError("createAuthor", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "createAuthor", http.StatusCreated, author)
+}
+
+func (h *Handler) getAllAuthors(w http.ResponseWriter, r *http.Request) {
+	authors, err := h.authorsService.GetAll(r.Context(), r.URL.Query().Get("name"))
+	if err != nil {
+		logError("getAllAuthors", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getAllAuthors", http.StatusOK, authors)
+}
+
+func (h *Handler) getAuthorByID(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getAuthorByID", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	author, err := h.authorsService.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrAuthorNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getAuthorByID", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getAuthorByID", http.StatusOK, author)
+}
+
+func (h *Handler) updateAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("updateAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("updateAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.UpdateAuthorInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("updateAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("updateAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.authorsService.Update(r.Context(), id, inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAuthorNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrAuthorExists):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			logError("updateAuthor", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteAuthor", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.authorsService.Delete(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAuthorNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrAuthorHasBooks):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			logError("deleteAuthor", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) mergeAuthors(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("mergeAuthors", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("mergeAuthors", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.MergeAuthorsInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("mergeAuthors", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("mergeAuthors", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.authorsService.Merge(r.Context(), id, inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAuthorNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrAuthorSelfMerge):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			logError("mergeAuthors", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) getAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getAuthorBooks", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	books, err := h.authorsService.GetBooks(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrAuthorNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getAuthorBooks", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getAuthorBooks", http.StatusOK, books)
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
 )
 
 func (h *Handler) getBookByID(w http.ResponseWriter, r *http.Request) {
@@ -58,6 +64,19 @@ func (h *Handler) createBook(w http.ResponseWriter, r *http.Request) {
 
 	err = h.booksService.Create(r.Context(), book)
 	if err != nil {
+		if isBookInputError(err) {
+			logError("createBook", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, domain.ErrISBNConflict) {
+			writeJSON(w, "createBook", http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
 		logError("createBook", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -76,6 +95,11 @@ func (h *Handler) deleteBook(w http.ResponseWriter, r *http.Request) {
 
 	err = h.booksService.Delete(r.Context(), id)
 	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
 		logError("deleteBook", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -85,7 +109,14 @@ func (h *Handler) deleteBook(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) getAllBooks(w http.ResponseWriter, r *http.Request) {
-	books, err := h.booksService.GetAll(r.Context())
+	filter, err := getBookFilterFromRequest(r)
+	if err != nil {
+		logError("getAllBooks", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	books, err := h.booksService.GetAll(r.Context(), filter)
 	if err != nil {
 		logError("getAllBooks", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -127,10 +158,184 @@ func (h *Handler) updateBook(w http.ResponseWriter, r *http.Request) {
 
 	err = h.booksService.Update(r.Context(), id, inp)
 	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if isBookInputError(err) {
+			logError("updateBook", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, domain.ErrISBNConflict) {
+			writeJSON(w, "updateBook", http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
 		logError("updateBook", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-}
\ No newline at end of file
+}
+
+func (h *Handler) getTrash(w http.ResponseWriter, r *http.Request) {
+	books, err := h.booksService.GetTrash(r.Context())
+	if err != nil {
+		logError("getTrash", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(books)
+	if err != nil {
+		logError("getTrash", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func (h *Handler) restoreBook(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("restoreBook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.booksService.Restore(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, domain.ErrISBNConflict) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		logError("restoreBook", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) getBookByISBN(w http.ResponseWriter, r *http.Request) {
+	book, err := h.booksService.GetByISBN(r.Context(), mux.Vars(r)["isbn"])
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidISBN):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, domain.ErrBookNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			logError("getBookByISBN", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	writeJSON(w, "getBookByISBN", http.StatusOK, book)
+}
+
+// isBookInputError reports whether err was caused by invalid book data sent by
+// the client.
+func isBookInputError(err error) bool {
+	return errors.Is(err, domain.ErrAuthorNotFound) ||
+		errors.Is(err, domain.ErrInvalidAuthorRole) ||
+		errors.Is(err, domain.ErrInvalidISBN) ||
+		errors.Is(err, domain.ErrISBNMismatch)
+}
+
+func getBookFilterFromRequest(r *http.Request) (domain.BookFilter, error) {
+	query := r.URL.Query()
+	filter := domain.BookFilter{
+		Title:  query.Get("title"),
+		Author: query.Get("author"),
+	}
+
+	if v := query.Get("author_id"); v != "" {
+		authorID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid author_id: %w", err)
+		}
+
+		filter.AuthorID = &authorID
+	}
+
+	if v := query.Get("genre_id"); v != "" {
+		genreID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid genre_id: %w", err)
+		}
+
+		filter.GenreID = &genreID
+	}
+
+	if v := query.Get("tags"); v != "" {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = domain.NormalizeTag(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+
+		filter.TagsMode = domain.TagsMode(query.Get("tags_mode"))
+		switch filter.TagsMode {
+		case "":
+			filter.TagsMode = domain.TagsAny
+		case domain.TagsAny, domain.TagsAll:
+		default:
+			return filter, fmt.Errorf("invalid tags_mode %q", filter.TagsMode)
+		}
+	}
+
+	if v := query.Get("min_rating"); v != "" {
+		rating, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid min_rating: %w", err)
+		}
+
+		filter.MinRating = &rating
+	}
+
+	if v := query.Get("max_rating"); v != "" {
+		rating, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid max_rating: %w", err)
+		}
+
+		filter.MaxRating = &rating
+	}
+
+	if v := query.Get("published_after"); v != "" {
+		date, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid published_after: %w", err)
+		}
+
+		filter.PublishedAfter = &date
+	}
+
+	if v := query.Get("published_before"); v != "" {
+		date, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid published_before: %w", err)
+		}
+
+		filter.PublishedBefore = &date
+	}
+
+	return filter, nil
+}
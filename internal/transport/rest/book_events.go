@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetry             = 3 * time.Second
+)
+
+// streamBookEvents sends book changes as Server-Sent Events. Clients resume
+// with the Last-Event-ID header; when the events they missed are no longer
+// available, a "reset" event tells them to reload the books instead. The
+// stream ends with an "expired" event when the access token expires, so that
+// clients reconnect with a fresh one.
+func (h *Handler) streamBookEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	token, err := getTokenFromRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	expiresAt, err := h.usersService.TokenExpiry(r.Context(), token)
+	if err != nil {
+		logError("streamBookEvents", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var (
+		lastID uint64
+		resume bool
+	)
+
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		lastID, resume = id, true
+	}
+
+	replay, complete, events, cancel := h.bookEvents.Subscribe(lastID, resume)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+
+	for _, event := range replay {
+		if err := writeBookEvent(w, event); err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// dropped for being too slow; the client reconnects and resumes
+				return
+			}
+
+			if err := writeBookEvent(w, event); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		case <-expiry.C:
+			fmt.Fprint(w, "event: expired\ndata: {}\n\n")
+			flusher.Flush()
+
+			return
+		}
+	}
+}
+
+func writeBookEvent(w http.ResponseWriter, event domain.BookEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logError("streamBookEvents", err)
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+type fakeTokenUsers struct {
+	User
+	expiresAt time.Time
+}
+
+func (f fakeTokenUsers) TokenExpiry(ctx context.Context, token string) (time.Time, error) {
+	return f.expiresAt, nil
+}
+
+type fakeBookEvents struct {
+	events chan domain.BookEvent
+}
+
+func (f fakeBookEvents) Subscribe(lastID uint64, resume bool) ([]domain.BookEvent, bool, <-chan domain.BookEvent, func()) {
+	return nil, true, f.events, func() {}
+}
+
+func TestStreamBookEventsEndsAtTokenExpiry(t *testing.T) {
+	events := make(chan domain.BookEvent, 1)
+	events <- domain.BookEvent{ID: 1, Type: domain.EventBookUpdated}
+
+	h := &Handler{
+		usersService: fakeTokenUsers{expiresAt: time.Now().Add(50 * time.Millisecond)},
+		bookEvents:   fakeBookEvents{events: events},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/books/events", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		h.streamBookEvents(rec, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream still open after the token expired")
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "id: 1\nevent: book.updated\n") {
+		t.Errorf("event sent before expiry missing from %q", body)
+	}
+
+	if !strings.HasSuffix(body, "event: expired\ndata: {}\n\n") {
+		t.Errorf("stream doesn't end with an expired event: %q", body)
+	}
+}
+
+func TestStreamBookEventsRequiresToken(t *testing.T) {
+	h := &Handler{bookEvents: fakeBookEvents{events: make(chan domain.BookEvent)}}
+
+	rec := httptest.NewRecorder()
+	h.streamBookEvents(rec, httptest.NewRequest(http.MethodGet, "/books/events", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
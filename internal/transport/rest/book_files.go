@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+// multipart overhead allowed on top of the file size limit
+const multipartSlack = 1 << 20
+
+func (h *Handler) getBookFiles(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getBookFiles", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	files, err := h.filesService.List(r.Context(), bookID)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getBookFiles", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getBookFiles", http.StatusOK, files)
+}
+
+// uploadBookFile takes the file from the "file" part of a multipart form. The
+// parts are streamed, so the file is never buffered by the handler.
+func (h *Handler) uploadBookFile(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("uploadBookFile", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.filesService.MaxSize()+multipartSlack)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		logError("uploadBookFile", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			// no more parts, or a malformed body
+			logError("uploadBookFile", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		file, err := h.filesService.Upload(r.Context(), bookID, part.FileName(), part)
+		part.Close()
+
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			switch {
+			case errors.Is(err, domain.ErrBookNotFound):
+				w.WriteHeader(http.StatusNotFound)
+			case errors.Is(err, domain.ErrFileTooLarge), errors.As(err, &maxBytesErr):
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+			case errors.Is(err, domain.ErrFileType):
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+			default:
+				logError("uploadBookFile", err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		writeJSON(w, "uploadBookFile", http.StatusCreated, file)
+		return
+	}
+}
+
+// downloadBookFile supports Range and If-Range requests, so interrupted
+// downloads can be resumed. The content hash doubles as a strong ETag.
+func (h *Handler) downloadBookFile(w http.ResponseWriter, r *http.Request) {
+	bookID, id, ok := getBookFileIDs(w, r, "downloadBookFile")
+	if !ok {
+		return
+	}
+
+	file, content, err := h.filesService.Open(r.Context(), bookID, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("downloadBookFile", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	w.Header().Set("ETag", `"`+file.SHA256+`"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	http.ServeContent(w, r, "", file.CreatedAt, content)
+}
+
+func (h *Handler) deleteBookFile(w http.ResponseWriter, r *http.Request) {
+	bookID, id, ok := getBookFileIDs(w, r, "deleteBookFile")
+	if !ok {
+		return
+	}
+
+	if err := h.filesService.Delete(r.Context(), bookID, id); err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("deleteBookFile", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func getBookFileIDs(w http.ResponseWriter, r *http.Request, handler string) (int64, int64, bool) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["fileID"], 10, 64)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return bookID, id, true
+}
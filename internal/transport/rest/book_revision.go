@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) getBookRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getBookRevisions", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.booksService.GetRevisions(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getBookRevisions", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getBookRevisions", http.StatusOK, revisions)
+}
+
+func (h *Handler) getBookRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getBookRevision", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rev, err := getRevisionFromRequest(r)
+	if err != nil {
+		logError("getBookRevision", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	revision, err := h.booksService.GetRevision(r.Context(), id, rev)
+	if err != nil {
+		if errors.Is(err, domain.ErrRevisionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getBookRevision", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getBookRevision", http.StatusOK, revision)
+}
+
+func (h *Handler) diffBookRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("diffBookRevisions", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		logError("diffBookRevisions", fmt.Errorf("invalid from: %w", err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		logError("diffBookRevisions", fmt.Errorf("invalid to: %w", err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.booksService.DiffRevisions(r.Context(), id, from, to)
+	if err != nil {
+		if errors.Is(err, domain.ErrRevisionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("diffBookRevisions", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "diffBookRevisions", http.StatusOK, diff)
+}
+
+func (h *Handler) revertBookRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("revertBookRevision", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rev, err := getRevisionFromRequest(r)
+	if err != nil {
+		logError("revertBookRevision", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	newRevision, err := h.booksService.RevertToRevision(r.Context(), id, rev)
+	if err != nil {
+		if errors.Is(err, domain.ErrRevisionNotFound) || errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, domain.ErrISBNConflict) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		logError("revertBookRevision", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "revertBookRevision", http.StatusOK, map[string]int{
+		"revision": newRevision,
+	})
+}
+
+func getRevisionFromRequest(r *http.Request) (int, error) {
+	rev, err := strconv.Atoi(mux.Vars(r)["rev"])
+	if err != nil {
+		return 0, err
+	}
+
+	if rev == 0 {
+		return 0, errors.New("revision can't be 0")
+	}
+
+	return rev, nil
+}
@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) setBookGenres(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("setBookGenres", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("setBookGenres", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.SetBookGenresInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("setBookGenres", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.booksService.SetGenres(r.Context(), id, inp.GenreIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrBookNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrGenreNotFound):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			logError("setBookGenres", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) getBookTags(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getBookTags", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.booksService.GetTags(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getBookTags", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getBookTags", http.StatusOK, tags)
+}
+
+func (h *Handler) addBookTags(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("addBookTags", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("addBookTags", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.TagsInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("addBookTags", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("addBookTags", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.booksService.AddTags(r.Context(), id, inp.Tags)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("addBookTags", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) removeBookTag(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("removeBookTag", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.booksService.RemoveTag(r.Context(), id, mux.Vars(r)["tag"])
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) || errors.Is(err, domain.ErrTagNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("removeBookTag", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) getBookFacets(w http.ResponseWriter, r *http.Request) {
+	filter, err := getBookFilterFromRequest(r)
+	if err != nil {
+		logError("getBookFacets", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	facets, err := h.booksService.Facets(r.Context(), filter)
+	if err != nil {
+		logError("getBookFacets", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getBookFacets", http.StatusOK, facets)
+}
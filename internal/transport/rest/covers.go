@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+// coverCacheControl lets clients and proxies keep covers forever: a new
+// upload is published under a new URL.
+const coverCacheControl = "public, max-age=31536000, immutable"
+
+func (h *Handler) uploadCover(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("uploadCover", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var declaredType string
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if mediaType != "application/octet-stream" {
+			declaredType = mediaType
+		}
+	}
+
+	if r.ContentLength > h.coversService.MaxSize() {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	coverURL, err := h.coversService.Upload(r.Context(), id, r.Body, declaredType)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrBookNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrCoverTooLarge):
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		case errors.Is(err, domain.ErrCoverType):
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		case errors.Is(err, domain.ErrInvalidCover):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			logError("uploadCover", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	writeJSON(w, "uploadCover", http.StatusOK, map[string]string{"cover_url": coverURL})
+}
+
+func (h *Handler) deleteCover(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteCover", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.coversService.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) || errors.Is(err, domain.ErrCoverNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("deleteCover", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// getCover serves a cover without authentication, so that it can be used
+// directly in image tags. The size query parameter picks a thumbnail or the
+// original upload and defaults to the large thumbnail.
+func (h *Handler) getCover(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getCover", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	size := domain.CoverLarge
+	if v := r.URL.Query().Get("size"); v != "" {
+		size = domain.CoverSize(v)
+		if !size.Valid() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	version := mux.Vars(r)["version"]
+
+	blob, info, err := h.coversService.Open(r.Context(), id, version, size)
+	if err != nil {
+		if errors.Is(err, domain.ErrCoverNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getCover", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Cache-Control", coverCacheControl)
+	w.Header().Set("ETag", `"`+version+"-"+string(size)+`"`)
+
+	http.ServeContent(w, r, "", info.ModTime, blob)
+}
@@ -0,0 +1,182 @@
+package rest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+// exportFlushEvery controls how many rows are written before the response is
+// flushed to the client.
+const exportFlushEvery = 100
+
+var exportContentTypes = map[domain.ExportFormat]string{
+	domain.ExportCSV:    "text/csv; charset=utf-8",
+	domain.ExportNDJSON: "application/x-ndjson",
+	domain.ExportJSON:   "application/json",
+}
+
+func (h *Handler) exportBooks(w http.ResponseWriter, r *http.Request) {
+	format := domain.ExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = domain.ExportJSON
+	}
+
+	if !format.Valid() {
+		logError("exportBooks", fmt.Errorf("unsupported export format %q", format))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	filter, err := getBookFilterFromRequest(r)
+	if err != nil {
+		logError("exportBooks", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("books-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Add("Content-Type", exportContentTypes[format])
+	w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	exporter := newBookExporter(format, w)
+	if err := exporter.begin(); err != nil {
+		logError("exportBooks", err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	written := 0
+
+	err = h.booksService.Export(r.Context(), filter, func(book domain.Book) error {
+		if err := exporter.write(book); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			if err := exporter.flush(); err != nil {
+				return err
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		// headers are already sent, the best we can do is to cut the stream short
+		logError("exportBooks", err)
+		return
+	}
+
+	if err := exporter.end(); err != nil {
+		logError("exportBooks", err)
+	}
+}
+
+type bookExporter interface {
+	begin() error
+	write(book domain.Book) error
+	flush() error
+	end() error
+}
+
+func newBookExporter(format domain.ExportFormat, w io.Writer) bookExporter {
+	switch format {
+	case domain.ExportCSV:
+		return &csvBookExporter{w: csv.NewWriter(w)}
+	case domain.ExportNDJSON:
+		return &ndjsonBookExporter{enc: json.NewEncoder(w)}
+	default:
+		return &jsonBookExporter{w: w}
+	}
+}
+
+type csvBookExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvBookExporter) begin() error {
+	return e.w.Write([]string{"id", "title", "author", "isbn10", "isbn13", "publish_date", "rating", "rating_count"})
+}
+
+func (e *csvBookExporter) write(book domain.Book) error {
+	return e.w.Write([]string{
+		strconv.FormatInt(book.ID, 10),
+		book.Title,
+		book.Author,
+		book.ISBN10,
+		book.ISBN13,
+		book.PublishDate.Format(time.RFC3339),
+		strconv.FormatFloat(book.Rating, 'f', 2, 64),
+		strconv.Itoa(book.RatingCount),
+	})
+}
+
+func (e *csvBookExporter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvBookExporter) end() error {
+	return e.flush()
+}
+
+type ndjsonBookExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonBookExporter) begin() error { return nil }
+
+func (e *ndjsonBookExporter) write(book domain.Book) error {
+	return e.enc.Encode(book)
+}
+
+func (e *ndjsonBookExporter) flush() error { return nil }
+
+func (e *ndjsonBookExporter) end() error { return nil }
+
+// jsonBookExporter writes a single JSON array, element by element.
+type jsonBookExporter struct {
+	w     io.Writer
+	first bool
+}
+
+func (e *jsonBookExporter) begin() error {
+	e.first = true
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonBookExporter) write(book domain.Book) error {
+	data, err := json.Marshal(book)
+	if err != nil {
+		return err
+	}
+
+	if !e.first {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.first = false
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonBookExporter) flush() error { return nil }
+
+func (e *jsonBookExporter) end() error {
+	_, err := io.WriteString(e.w, "]")
+	return err
+}
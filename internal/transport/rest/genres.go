@@ -0,0 +1,161 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func (h *Handler) createGenre(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("createGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.GenreInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("createGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("createGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	genre, err := h.genresService.Create(r.Context(), inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrGenreExists):
+			w.WriteHeader(http.StatusConflict)
+		case errors.Is(err, domain.ErrGenreNotFound):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, domain.ErrEmptyGenreSlug):
+			writeJSON(w, "createGenre", http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			logError("createGenre", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	writeJSON(w, "createGenre", http.StatusCreated, genre)
+}
+
+func (h *Handler) getGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.genresService.GetTree(r.Context())
+	if err != nil {
+		logError("getGenres", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getGenres", http.StatusOK, genres)
+}
+
+func (h *Handler) getGenreByID(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getGenreByID", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	genre, err := h.genresService.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrGenreNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getGenreByID", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getGenreByID", http.StatusOK, genre)
+}
+
+func (h *Handler) updateGenre(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("updateGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("updateGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.GenreInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("updateGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("updateGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.genresService.Update(r.Context(), id, inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrGenreNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrGenreExists):
+			w.WriteHeader(http.StatusConflict)
+		case errors.Is(err, domain.ErrGenreCycle):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, domain.ErrEmptyGenreSlug):
+			writeJSON(w, "updateGenre", http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			logError("updateGenre", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteGenre(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteGenre", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.genresService.Delete(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrGenreNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrGenreInUse):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			logError("deleteGenre", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -2,11 +2,16 @@ package rest
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/pkg/realip"
+	"github.com/crud-app/pkg/storage"
 
 	"github.com/gorilla/mux"
 )
@@ -14,55 +19,377 @@ import (
 type Books interface {
 	Create(ctx context.Context, book domain.Book) error
 	GetByID(ctx context.Context, id int64) (domain.Book, error)
-	GetAll(ctx context.Context) ([]domain.Book, error)
+	GetByISBN(ctx context.Context, isbn string) (domain.Book, error)
+	GetAll(ctx context.Context, filter domain.BookFilter) ([]domain.Book, error)
+	Export(ctx context.Context, filter domain.BookFilter, fn func(domain.Book) error) error
 	Delete(ctx context.Context, id int64) error
 	Update(ctx context.Context, id int64, inp domain.UpdateBookInput) error
+	GetTrash(ctx context.Context) ([]domain.Book, error)
+	Restore(ctx context.Context, id int64) error
+	GetRevisions(ctx context.Context, id int64) ([]domain.BookRevision, error)
+	GetRevision(ctx context.Context, id int64, revision int) (domain.BookRevision, error)
+	DiffRevisions(ctx context.Context, id int64, from, to int) (domain.BookRevisionDiff, error)
+	RevertToRevision(ctx context.Context, id int64, revision int) (int, error)
+	Facets(ctx context.Context, filter domain.BookFilter) (domain.BookFacets, error)
+	SetGenres(ctx context.Context, id int64, genreIDs []int64) error
+	GetTags(ctx context.Context, id int64) ([]string, error)
+	AddTags(ctx context.Context, id int64, tags []string) error
+	RemoveTag(ctx context.Context, id int64, tag string) error
 }
 
 type User interface {
 	SignUp(ctx context.Context, inp domain.SignUpInput) error
 	SignIn(ctx context.Context, inp domain.SignInInput) (string, string, error)
 	ParseToken(ctx context.Context, accessToken string) (int64, error)
+	TokenExpiry(ctx context.Context, accessToken string) (time.Time, error)
 	RefreshTokens(ctx context.Context, refreshToken string) (string, string, error)
+	GetRole(ctx context.Context, userID int64) (domain.Role, error)
+	Unlock(ctx context.Context, inp domain.UnlockInput) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, inp domain.ResetPasswordInput) error
+	Profile(ctx context.Context) (domain.User, error)
+	UpdateProfile(ctx context.Context, inp domain.UpdateUserInput) (domain.User, error)
+	ChangePassword(ctx context.Context, inp domain.ChangePasswordInput) (string, string, error)
+	DeleteAccount(ctx context.Context) (time.Time, error)
+	SearchUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, error)
+	GetUser(ctx context.Context, id int64) (domain.User, error)
+	DisableUser(ctx context.Context, id int64) error
+	EnableUser(ctx context.Context, id int64) error
+	SetRole(ctx context.Context, id int64, role domain.Role) error
+	ForcePasswordReset(ctx context.Context, id int64) error
+	RevokeSessions(ctx context.Context, id int64) (int, error)
+	EnrollMFA(ctx context.Context) (domain.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, inp domain.MFACodeInput) ([]string, error)
+	DisableMFA(ctx context.Context, inp domain.MFACodeInput) error
+	VerifyMFA(ctx context.Context, inp domain.MFAChallengeInput) (string, string, error)
+}
+
+type Authors interface {
+	Create(ctx context.Context, inp domain.AuthorInput) (domain.Author, error)
+	GetByID(ctx context.Context, id int64) (domain.Author, error)
+	GetAll(ctx context.Context, name string) ([]domain.Author, error)
+	Update(ctx context.Context, id int64, inp domain.UpdateAuthorInput) error
+	Delete(ctx context.Context, id int64) error
+	Merge(ctx context.Context, target int64, inp domain.MergeAuthorsInput) error
+	GetBooks(ctx context.Context, id int64) ([]domain.Book, error)
+}
+
+type Genres interface {
+	Create(ctx context.Context, inp domain.GenreInput) (domain.Genre, error)
+	GetByID(ctx context.Context, id int64) (domain.Genre, error)
+	GetTree(ctx context.Context) ([]domain.Genre, error)
+	Update(ctx context.Context, id int64, inp domain.GenreInput) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type Reviews interface {
+	List(ctx context.Context, bookID int64, filter domain.ReviewFilter) ([]domain.Review, error)
+	GetMine(ctx context.Context, bookID int64) (domain.Review, error)
+	Save(ctx context.Context, bookID int64, inp domain.ReviewInput) (domain.Review, error)
+	DeleteMine(ctx context.Context, bookID int64) error
+	Vote(ctx context.Context, bookID, reviewID int64) error
+	Unvote(ctx context.Context, bookID, reviewID int64) error
+	Report(ctx context.Context, bookID, reviewID int64, inp domain.ReportReviewInput) error
+	SetHidden(ctx context.Context, bookID, reviewID int64, hidden bool) error
+}
+
+type Shelves interface {
+	GetAll(ctx context.Context) ([]domain.Shelf, error)
+	Create(ctx context.Context, inp domain.ShelfInput) (domain.Shelf, error)
+	GetByID(ctx context.Context, id int64) (domain.Shelf, error)
+	GetShared(ctx context.Context, slug string) (domain.Shelf, error)
+	Rename(ctx context.Context, id int64, inp domain.ShelfInput) error
+	Delete(ctx context.Context, id int64) error
+	AddBook(ctx context.Context, id, bookID int64) error
+	RemoveBook(ctx context.Context, id, bookID int64) error
+	Reorder(ctx context.Context, id int64, bookIDs []int64) error
+	Share(ctx context.Context, id int64) (string, error)
+	Unshare(ctx context.Context, id int64) error
+}
+
+type Reading interface {
+	RecordSession(ctx context.Context, bookID int64, inp domain.ReadingSessionInput) (domain.ReadingSession, error)
+	GetSessions(ctx context.Context, bookID int64) ([]domain.ReadingSession, error)
+	DeleteSession(ctx context.Context, bookID, id int64) error
+	SetGoal(ctx context.Context, inp domain.ReadingGoalInput) error
+	Stats(ctx context.Context, year int) (domain.ReadingStats, error)
+}
+
+type Covers interface {
+	MaxSize() int64
+	Upload(ctx context.Context, bookID int64, r io.Reader, declaredType string) (string, error)
+	Delete(ctx context.Context, bookID int64) error
+	Open(ctx context.Context, bookID int64, version string, size domain.CoverSize) (io.ReadSeekCloser, storage.Info, error)
+}
+
+type BookFiles interface {
+	MaxSize() int64
+	List(ctx context.Context, bookID int64) ([]domain.BookFile, error)
+	Upload(ctx context.Context, bookID int64, name string, r io.Reader) (domain.BookFile, error)
+	Open(ctx context.Context, bookID, id int64) (domain.BookFile, io.ReadSeekCloser, error)
+	Delete(ctx context.Context, bookID, id int64) error
+}
+
+type Webhooks interface {
+	Create(ctx context.Context, inp domain.WebhookInput) (domain.WebhookSubscription, error)
+	GetAll(ctx context.Context) ([]domain.WebhookSubscription, error)
+	GetByID(ctx context.Context, id int64) (domain.WebhookSubscription, error)
+	Update(ctx context.Context, id int64, inp domain.UpdateWebhookInput) error
+	Delete(ctx context.Context, id int64) error
+	Deliveries(ctx context.Context, id int64, limit, offset int) ([]domain.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id, deliveryID int64) (domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id, deliveryID int64) (domain.WebhookDelivery, error)
+}
+
+type BookEvents interface {
+	Subscribe(lastID uint64, resume bool) ([]domain.BookEvent, bool, <-chan domain.BookEvent, func())
+}
+
+type Audit interface {
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
 }
 
 type Handler struct {
-	booksService Books
-	usersService User
+	booksService    Books
+	usersService    User
+	auditService    Audit
+	authorsService  Authors
+	genresService   Genres
+	reviewsService  Reviews
+	shelvesService  Shelves
+	readingService  Reading
+	coversService   Covers
+	filesService    BookFiles
+	webhooksService Webhooks
+	bookEvents      BookEvents
+	limits          RateLimits
+	proxies         realip.Proxies
 }
 
-func NewHandler(books Books, users User) *Handler {
+func NewHandler(books Books, users User, audit Audit, authors Authors, genres Genres, reviews Reviews,
+	shelves Shelves, reading Reading, covers Covers, files BookFiles, webhooks Webhooks, bookEvents BookEvents,
+	limits RateLimits, proxies realip.Proxies) *Handler {
 	return &Handler{
-		booksService: books,
-		usersService: users,
+		booksService:    books,
+		usersService:    users,
+		auditService:    audit,
+		authorsService:  authors,
+		genresService:   genres,
+		reviewsService:  reviews,
+		shelvesService:  shelves,
+		readingService:  reading,
+		coversService:   covers,
+		filesService:    files,
+		webhooksService: webhooks,
+		bookEvents:      bookEvents,
+		limits:          limits,
+		proxies:         proxies,
 	}
 }
 
 func (h *Handler) InitRouter() *mux.Router {
 	r := mux.NewRouter()
+	r.Use(h.requestMetaMiddleware)
 	r.Use(loggingMiddleware)
+	r.Use(h.shedLoad)
 
 	auth := r.PathPrefix("/auth").Subrouter()
 	{
+		auth.Use(h.rateLimit("auth"))
+
 		auth.HandleFunc("/sign-up", h.signUp).Methods(http.MethodPost)
 		auth.HandleFunc("/sign-in", h.signIn).Methods(http.MethodGet)
 		auth.HandleFunc("/refresh", h.refresh).Methods(http.MethodGet)
+		auth.HandleFunc("/verify", h.verifyEmail).Methods(http.MethodGet)
+		auth.HandleFunc("/verify/resend", h.resendVerification).Methods(http.MethodPost)
+		auth.HandleFunc("/password/forgot", h.forgotPassword).Methods(http.MethodPost)
+		auth.HandleFunc("/password/reset", h.resetPassword).Methods(http.MethodPost)
+		auth.HandleFunc("/mfa", h.verifyMFA).Methods(http.MethodPost)
 	}
 
 	books := r.PathPrefix("/books").Subrouter()
 	{
 		books.Use(h.authMiddleware)
+		books.Use(h.rateLimit("books"))
 
 		books.HandleFunc("", h.createBook).Methods(http.MethodPost)
 		books.HandleFunc("", h.getAllBooks).Methods(http.MethodGet)
+		books.HandleFunc("/export", h.exportBooks).Methods(http.MethodGet)
+		books.HandleFunc("/events", h.streamBookEvents).Methods(http.MethodGet).Name(streamRoute)
+		books.HandleFunc("/trash", h.getTrash).Methods(http.MethodGet)
+		books.HandleFunc("/facets", h.getBookFacets).Methods(http.MethodGet)
+		books.HandleFunc("/isbn/{isbn}", h.getBookByISBN).Methods(http.MethodGet)
 		books.HandleFunc("/{id:[0-9]+}", h.getBookByID).Methods(http.MethodGet)
 		books.HandleFunc("/{id:[0-9]+}", h.deleteBook).Methods(http.MethodDelete)
 		books.HandleFunc("/{id:[0-9]+}", h.updateBook).Methods(http.MethodPut)
+		books.HandleFunc("/{id:[0-9]+}/restore", h.restoreBook).Methods(http.MethodPost)
+		books.HandleFunc("/{id:[0-9]+}/revisions", h.getBookRevisions).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/revisions/diff", h.diffBookRevisions).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/revisions/{rev:[0-9]+}", h.getBookRevision).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/revisions/{rev:[0-9]+}/revert", h.revertBookRevision).Methods(http.MethodPost)
+		books.HandleFunc("/{id:[0-9]+}/cover", h.uploadCover).Methods(http.MethodPut)
+		books.HandleFunc("/{id:[0-9]+}/cover", h.deleteCover).Methods(http.MethodDelete)
+		books.HandleFunc("/{id:[0-9]+}/files", h.getBookFiles).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/files", h.uploadBookFile).Methods(http.MethodPost)
+		books.HandleFunc("/{id:[0-9]+}/files/{fileID:[0-9]+}", h.downloadBookFile).Methods(http.MethodGet, http.MethodHead)
+		books.HandleFunc("/{id:[0-9]+}/files/{fileID:[0-9]+}", h.deleteBookFile).Methods(http.MethodDelete)
+		books.HandleFunc("/{id:[0-9]+}/genres", h.setBookGenres).Methods(http.MethodPut)
+		books.HandleFunc("/{id:[0-9]+}/tags", h.getBookTags).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/tags", h.addBookTags).Methods(http.MethodPost)
+		books.HandleFunc("/{id:[0-9]+}/tags/{tag}", h.removeBookTag).Methods(http.MethodDelete)
+		books.HandleFunc("/{id:[0-9]+}/reviews", h.getBookReviews).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/reviews/me", h.getMyReview).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/reviews/me", h.saveMyReview).Methods(http.MethodPut)
+		books.HandleFunc("/{id:[0-9]+}/reviews/me", h.deleteMyReview).Methods(http.MethodDelete)
+		books.HandleFunc("/{id:[0-9]+}/reviews/{reviewID:[0-9]+}/helpful", h.voteReview).Methods(http.MethodPost)
+		books.HandleFunc("/{id:[0-9]+}/reviews/{reviewID:[0-9]+}/helpful", h.unvoteReview).Methods(http.MethodDelete)
+		books.HandleFunc("/{id:[0-9]+}/reviews/{reviewID:[0-9]+}/report", h.reportReview).Methods(http.MethodPost)
+		books.HandleFunc("/{id:[0-9]+}/sessions", h.getReadingSessions).Methods(http.MethodGet)
+		books.HandleFunc("/{id:[0-9]+}/sessions", h.recordReadingSession).Methods(http.MethodPost)
+		books.HandleFunc("/{id:[0-9]+}/sessions/{sessionID:[0-9]+}", h.deleteReadingSession).Methods(http.MethodDelete)
+
+		moderation := books.NewRoute().Subrouter()
+		moderation.Use(h.requireRole(domain.RoleAdmin))
+
+		moderation.HandleFunc("/{id:[0-9]+}/reviews/{reviewID:[0-9]+}/hide", h.hideReview).Methods(http.MethodPost)
+		moderation.HandleFunc("/{id:[0-9]+}/reviews/{reviewID:[0-9]+}/unhide", h.unhideReview).Methods(http.MethodPost)
+	}
+
+	authors := r.PathPrefix("/authors").Subrouter()
+	{
+		authors.Use(h.authMiddleware)
+		authors.Use(h.rateLimit("authors"))
+
+		authors.HandleFunc("", h.createAuthor).Methods(http.MethodPost)
+		authors.HandleFunc("", h.getAllAuthors).Methods(http.MethodGet)
+		authors.HandleFunc("/{id:[0-9]+}", h.getAuthorByID).Methods(http.MethodGet)
+		authors.HandleFunc("/{id:[0-9]+}", h.updateAuthor).Methods(http.MethodPut)
+		authors.HandleFunc("/{id:[0-9]+}", h.deleteAuthor).Methods(http.MethodDelete)
+		authors.HandleFunc("/{id:[0-9]+}/merge", h.mergeAuthors).Methods(http.MethodPost)
+		authors.HandleFunc("/{id:[0-9]+}/books", h.getAuthorBooks).Methods(http.MethodGet)
+	}
+
+	genres := r.PathPrefix("/genres").Subrouter()
+	{
+		genres.Use(h.authMiddleware)
+		genres.Use(h.rateLimit("genres"))
+
+		genres.HandleFunc("", h.getGenres).Methods(http.MethodGet)
+		genres.HandleFunc("/{id:[0-9]+}", h.getGenreByID).Methods(http.MethodGet)
+
+		// the genre hierarchy is curated by editors
+		curated := genres.NewRoute().Subrouter()
+		curated.Use(h.requireRole(domain.RoleEditor, domain.RoleAdmin))
+
+		curated.HandleFunc("", h.createGenre).Methods(http.MethodPost)
+		curated.HandleFunc("/{id:[0-9]+}", h.updateGenre).Methods(http.MethodPut)
+		curated.HandleFunc("/{id:[0-9]+}", h.deleteGenre).Methods(http.MethodDelete)
+	}
+
+	shelves := r.PathPrefix("/shelves").Subrouter()
+	{
+		shelves.Use(h.authMiddleware)
+		shelves.Use(h.rateLimit("shelves"))
+
+		shelves.HandleFunc("", h.getShelves).Methods(http.MethodGet)
+		shelves.HandleFunc("", h.createShelf).Methods(http.MethodPost)
+		shelves.HandleFunc("/{id:[0-9]+}", h.getShelf).Methods(http.MethodGet)
+		shelves.HandleFunc("/{id:[0-9]+}", h.renameShelf).Methods(http.MethodPatch)
+		shelves.HandleFunc("/{id:[0-9]+}", h.deleteShelf).Methods(http.MethodDelete)
+		shelves.HandleFunc("/{id:[0-9]+}/books", h.addShelfBook).Methods(http.MethodPost)
+		shelves.HandleFunc("/{id:[0-9]+}/books/{bookID:[0-9]+}", h.removeShelfBook).Methods(http.MethodDelete)
+		shelves.HandleFunc("/{id:[0-9]+}/order", h.reorderShelf).Methods(http.MethodPut)
+		shelves.HandleFunc("/{id:[0-9]+}/share", h.shareShelf).Methods(http.MethodPost)
+		shelves.HandleFunc("/{id:[0-9]+}/share", h.unshareShelf).Methods(http.MethodDelete)
+	}
+
+	me := r.PathPrefix("/me").Subrouter()
+	{
+		me.Use(h.authMiddleware)
+		me.Use(h.rateLimit("me"))
+
+		me.HandleFunc("", h.getProfile).Methods(http.MethodGet)
+		me.HandleFunc("", h.updateProfile).Methods(http.MethodPatch)
+		me.HandleFunc("", h.deleteAccount).Methods(http.MethodDelete)
+		me.HandleFunc("/password", h.changePassword).Methods(http.MethodPost)
+		me.HandleFunc("/mfa/enroll", h.enrollMFA).Methods(http.MethodPost)
+		me.HandleFunc("/mfa/confirm", h.confirmMFA).Methods(http.MethodPost)
+		me.HandleFunc("/mfa/disable", h.disableMFA).Methods(http.MethodPost)
+		me.HandleFunc("/stats", h.getMyStats).Methods(http.MethodGet)
+		me.HandleFunc("/goal", h.setReadingGoal).Methods(http.MethodPut)
+	}
+
+	covers := r.PathPrefix("/covers").Subrouter()
+	{
+		covers.Use(h.rateLimit("covers"))
+
+		covers.HandleFunc("/{id:[0-9]+}/{version}", h.getCover).Methods(http.MethodGet, http.MethodHead)
+	}
+
+	public := r.PathPrefix("/public").Subrouter()
+	{
+		public.Use(h.rateLimit("public"))
+
+		public.HandleFunc("/shelves/{slug}", h.getSharedShelf).Methods(http.MethodGet)
+	}
+
+	webhooks := r.PathPrefix("/webhooks").Subrouter()
+	{
+		webhooks.Use(h.authMiddleware)
+		webhooks.Use(h.rateLimit("webhooks"))
+		webhooks.Use(h.requireRole(domain.RoleAdmin))
+
+		webhooks.HandleFunc("", h.getWebhooks).Methods(http.MethodGet)
+		webhooks.HandleFunc("", h.createWebhook).Methods(http.MethodPost)
+		webhooks.HandleFunc("/{id:[0-9]+}", h.getWebhook).Methods(http.MethodGet)
+		webhooks.HandleFunc("/{id:[0-9]+}", h.updateWebhook).Methods(http.MethodPatch)
+		webhooks.HandleFunc("/{id:[0-9]+}", h.deleteWebhook).Methods(http.MethodDelete)
+		webhooks.HandleFunc("/{id:[0-9]+}/deliveries", h.getWebhookDeliveries).Methods(http.MethodGet)
+		webhooks.HandleFunc("/{id:[0-9]+}/deliveries/{deliveryID:[0-9]+}", h.getWebhookDelivery).Methods(http.MethodGet)
+		webhooks.HandleFunc("/{id:[0-9]+}/deliveries/{deliveryID:[0-9]+}/redeliver", h.redeliverWebhook).Methods(http.MethodPost)
+	}
+
+	admin := r.PathPrefix("/admin").Subrouter()
+	{
+		admin.Use(h.authMiddleware)
+		admin.Use(h.rateLimit("admin"))
+		admin.Use(h.requireRole(domain.RoleAdmin))
+
+		admin.HandleFunc("/unlock", h.unlock).Methods(http.MethodPost)
+		admin.HandleFunc("/users", h.searchUsers).Methods(http.MethodGet)
+		admin.HandleFunc("/users/{id:[0-9]+}", h.getUser).Methods(http.MethodGet)
+		admin.HandleFunc("/users/{id:[0-9]+}/disable", h.disableUser).Methods(http.MethodPost)
+		admin.HandleFunc("/users/{id:[0-9]+}/enable", h.enableUser).Methods(http.MethodPost)
+		admin.HandleFunc("/users/{id:[0-9]+}/role", h.setUserRole).Methods(http.MethodPut)
+		admin.HandleFunc("/users/{id:[0-9]+}/password-reset", h.forcePasswordReset).Methods(http.MethodPost)
+		admin.HandleFunc("/users/{id:[0-9]+}/sessions", h.revokeUserSessions).Methods(http.MethodDelete)
+	}
+
+	audit := r.PathPrefix("/audit").Subrouter()
+	{
+		audit.Use(h.authMiddleware)
+		audit.Use(h.rateLimit("audit"))
+		audit.Use(h.requireRole(domain.RoleAdmin))
+
+		audit.HandleFunc("", h.getAuditLog).Methods(http.MethodGet)
 	}
 
 	return r
 }
 
+// MountGraphQL serves the GraphQL endpoint at /graphql on a router made by
+// InitRouter. Its book queries and mutations draw from the same "books" rate
+// limit as the REST routes, keyed by the authenticated user.
+func (h *Handler) MountGraphQL(r *mux.Router, graphql http.Handler) {
+	gql := r.Path("/graphql").Subrouter()
+	gql.Use(h.authMiddleware)
+	gql.Use(h.rateLimit("books"))
+
+	gql.Handle("", graphql).Methods(http.MethodGet, http.MethodPost)
+}
+
 func getIdFromRequest(r *http.Request) (int64, error) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -76,3 +403,39 @@ func getIdFromRequest(r *http.Request) (int64, error) {
 
 	return id, nil
 }
+
+// getPageFromRequest reads the limit and offset query parameters. Zero values
+// are left for the service layer to replace with its defaults.
+func getPageFromRequest(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+
+	var limit, offset int
+	var err error
+
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("invalid limit")
+		}
+	}
+
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("invalid offset")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func writeJSON(w http.ResponseWriter, handler string, status int, v interface{}) {
+	response, err := json.Marshal(v)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(response)
+}
@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func (h *Handler) enrollMFA(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := h.usersService.EnrollMFA(r.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			w.WriteHeader(http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+			writeJSON(w, "enrollMFA", http.StatusConflict, map[string]string{"error": err.Error()})
+		default:
+			logError("enrollMFA", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	writeJSON(w, "enrollMFA", http.StatusOK, enrollment)
+}
+
+// confirmMFA answers with the recovery codes, which can't be shown again.
+func (h *Handler) confirmMFA(w http.ResponseWriter, r *http.Request) {
+	inp, ok := readMFACodeInput(w, r, "confirmMFA")
+	if !ok {
+		return
+	}
+
+	codes, err := h.usersService.ConfirmMFA(r.Context(), inp)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+			writeJSON(w, "confirmMFA", http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+
+		handleMFAError(w, "confirmMFA", err)
+		return
+	}
+
+	writeJSON(w, "confirmMFA", http.StatusOK, map[string][]string{"recovery_codes": codes})
+}
+
+func (h *Handler) disableMFA(w http.ResponseWriter, r *http.Request) {
+	inp, ok := readMFACodeInput(w, r, "disableMFA")
+	if !ok {
+		return
+	}
+
+	if err := h.usersService.DisableMFA(r.Context(), inp); err != nil {
+		handleMFAError(w, "disableMFA", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifyMFA completes a sign-in, answering like signIn does without
+// two-factor authentication.
+func (h *Handler) verifyMFA(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.MFAChallengeInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken, err := h.usersService.VerifyMFA(r.Context(), inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMFAToken):
+			writeJSON(w, "verifyMFA", http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		case errors.Is(err, domain.ErrUserDisabled):
+			writeJSON(w, "verifyMFA", http.StatusForbidden, map[string]string{"error": err.Error()})
+		default:
+			handleMFAError(w, "verifyMFA", err)
+		}
+
+		return
+	}
+
+	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
+	writeJSON(w, "verifyMFA", http.StatusOK, map[string]string{"token": accessToken})
+}
+
+func readMFACodeInput(w http.ResponseWriter, r *http.Request, handler string) (domain.MFACodeInput, bool) {
+	var inp domain.MFACodeInput
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return inp, false
+	}
+
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return inp, false
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return inp, false
+	}
+
+	return inp, true
+}
+
+// handleMFAError answers the errors common to the handlers checking codes.
+func handleMFAError(w http.ResponseWriter, handler string, err error) {
+	var locked *domain.LockedError
+
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrMFANotEnrolled):
+		writeJSON(w, handler, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		writeJSON(w, handler, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.As(err, &locked):
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(time.Until(locked.Until))))
+		writeJSON(w, handler, http.StatusTooManyRequests, map[string]interface{}{
+			"error":        err.Error(),
+			"locked_until": locked.Until,
+		})
+	default:
+		logError(handler, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
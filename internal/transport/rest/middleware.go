@@ -2,10 +2,14 @@ package rest
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/crud-app/internal/domain"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -15,16 +19,40 @@ const (
 	ctxUserID CtxValue = iota
 )
 
+const requestIDHeader = "X-Request-ID"
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.WithFields(log.Fields{
-			"method": r.Method,
-			"uri":    r.RequestURI,
+			"method":     r.Method,
+			"uri":        r.RequestURI,
+			"request_id": domain.ActorFromContext(r.Context()).RequestID,
 		}).Info()
 		next.ServeHTTP(w, r)
 	})
 }
 
+// requestMetaMiddleware assigns a request ID and puts the caller's network
+// details into the context, so that services can attribute their actions.
+func (h *Handler) requestMetaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+
+		ctx := domain.WithActor(r.Context(), domain.Actor{
+			IP:        h.proxies.ClientIP(r.RemoteAddr, r.Header.Values("X-Forwarded-For")),
+			UserAgent: r.UserAgent(),
+			RequestID: requestID,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token, err := getTokenFromRequest(r)
@@ -36,18 +64,67 @@ func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 
 		userId, err := h.usersService.ParseToken(r.Context(), token)
 		if err != nil {
+			if errors.Is(err, domain.ErrUserDisabled) {
+				writeJSON(w, "authMiddleware", http.StatusForbidden, map[string]string{"error": err.Error()})
+				return
+			}
+
 			logError("authMiddleware", err)
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
+		actor := domain.ActorFromContext(r.Context())
+		actor.UserID = userId
+
 		ctx := context.WithValue(r.Context(), ctxUserID, userId)
+		ctx = domain.WithActor(ctx, actor)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// requireRole only lets through users having one of the given roles. It must be
+// used after authMiddleware.
+func (h *Handler) requireRole(roles ...domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, ok := r.Context().Value(ctxUserID).(int64)
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			role, err := h.usersService.GetRole(r.Context(), userId)
+			if err != nil {
+				if errors.Is(err, domain.ErrUserNotFound) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				if errors.Is(err, domain.ErrMFAEnrollmentRequired) {
+					writeJSON(w, "requireRole", http.StatusForbidden, map[string]string{"error": err.Error()})
+					return
+				}
+
+				logError("requireRole", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+}
+
 func getTokenFromRequest(r *http.Request) (string, error) {
 	header := r.Header.Get("Authorization")
 	if header == "" {
@@ -64,4 +141,13 @@ func getTokenFromRequest(r *http.Request) (string, error) {
 	}
 
 	return headerParts[1], nil
-}
\ No newline at end of file
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
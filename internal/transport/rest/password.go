@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/crud-app/internal/domain"
+)
+
+// forgotPassword answers the same whether or not the email address belongs
+// to an account.
+func (h *Handler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ForgotPasswordInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.ForgotPassword(r.Context(), inp.Email); err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ResetPasswordInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.ResetPassword(r.Context(), inp); err != nil {
+		if errors.Is(err, domain.ErrInvalidResetToken) {
+			writeJSON(w, "resetPassword", http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
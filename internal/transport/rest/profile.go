@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/crud-app/internal/domain"
+)
+
+func (h *Handler) getProfile(w http.ResponseWriter, r *http.Request) {
+	user, err := h.usersService.Profile(r.Context())
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		logError("getProfile", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getProfile", http.StatusOK, user)
+}
+
+func (h *Handler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("updateProfile", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.UpdateUserInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("updateProfile", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("updateProfile", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersService.UpdateProfile(r.Context(), inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			w.WriteHeader(http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrEmailTaken):
+			writeJSON(w, "updateProfile", http.StatusConflict, map[string]string{"error": err.Error()})
+		default:
+			logError("updateProfile", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	writeJSON(w, "updateProfile", http.StatusOK, user)
+}
+
+// changePassword answers like signIn, with the tokens that replace the ones
+// revoked by the change.
+func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ChangePasswordInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken, err := h.usersService.ChangePassword(r.Context(), inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			w.WriteHeader(http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrWrongPassword):
+			writeJSON(w, "changePassword", http.StatusForbidden, map[string]string{"error": err.Error()})
+		default:
+			logError("changePassword", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
+	writeJSON(w, "changePassword", http.StatusOK, map[string]string{"token": accessToken})
+}
+
+func (h *Handler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	deleteAt, err := h.usersService.DeleteAccount(r.Context())
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		logError("deleteAccount", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "deleteAccount", http.StatusAccepted, map[string]interface{}{"delete_at": deleteAt})
+}
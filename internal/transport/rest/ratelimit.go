@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/pkg/ratelimit"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultRateLimitGroup applies to the route groups that have no limit of
+// their own.
+const defaultRateLimitGroup = "default"
+
+// overloadRetryAfter is what clients are told to wait for when the server
+// sheds load.
+const overloadRetryAfter = time.Second
+
+// streamRoute names the routes that hold their connection open for as long
+// as the client wants, which the concurrency limit leaves out.
+const streamRoute = "stream"
+
+type RateLimiter interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+// RateLimits configures the limits of InitRouter. Groups are keyed by the
+// route group, such as "auth" or "books", and Store may be nil to disable rate
+// limiting. MaxInFlight caps the requests served at once, zero meaning no cap.
+type RateLimits struct {
+	Store       RateLimiter
+	Groups      map[string]ratelimit.Limit
+	MaxInFlight int
+}
+
+// rateLimit limits the requests to a route group by user, or by IP address for
+// anonymous ones. On authenticated groups it must be used after
+// authMiddleware.
+func (h *Handler) rateLimit(group string) mux.MiddlewareFunc {
+	limit, ok := h.limits.Groups[group]
+	if !ok {
+		limit, ok = h.limits.Groups[defaultRateLimitGroup]
+	}
+
+	if h.limits.Store == nil || !ok || limit.Rate <= 0 || limit.Burst <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.Window()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := group + ":ip:" + domain.ActorFromContext(r.Context()).IP
+			if userID, ok := r.Context().Value(ctxUserID).(int64); ok {
+				key = group + ":user:" + strconv.FormatInt(userID, 10)
+			}
+
+			res, err := h.limits.Store.Take(r.Context(), key, limit)
+			if err != nil {
+				// an unavailable store shouldn't take the API down with it
+				logError("rateLimit", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			w.Header().Set("RateLimit-Policy", policy)
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// shedLoad answers 503 straight away once MaxInFlight requests are being
+// served, rather than letting them queue up.
+func (h *Handler) shedLoad(next http.Handler) http.Handler {
+	if h.limits.MaxInFlight <= 0 {
+		return next
+	}
+
+	inFlight := make(chan struct{}, h.limits.MaxInFlight)
+	retryAfter := strconv.Itoa(ceilSeconds(overloadRetryAfter))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil && route.GetName() == streamRoute {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+			defer func() { <-inFlight }()
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/crud-app/internal/domain"
+	"github.com/crud-app/pkg/ratelimit"
+)
+
+type fakeAuthUsers struct {
+	User
+}
+
+func (fakeAuthUsers) ParseToken(ctx context.Context, token string) (int64, error) {
+	if token != "token" {
+		return 0, errors.New("invalid token")
+	}
+
+	return 1, nil
+}
+
+type fakeListBooks struct {
+	Books
+}
+
+func (fakeListBooks) GetAll(ctx context.Context, filter domain.BookFilter) ([]domain.Book, error) {
+	return []domain.Book{}, nil
+}
+
+func TestGraphQLSharesBooksRateLimit(t *testing.T) {
+	h := &Handler{
+		booksService: fakeListBooks{},
+		usersService: fakeAuthUsers{},
+		limits: RateLimits{
+			Store:  ratelimit.NewMemory(),
+			Groups: map[string]ratelimit.Limit{"books": {Rate: 0.001, Burst: 2}},
+		},
+	}
+
+	router := h.InitRouter()
+	h.MountGraphQL(router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{http.MethodPost, "/graphql", "", http.StatusUnauthorized},
+		{http.MethodGet, "/books", "token", http.StatusOK},
+		{http.MethodPost, "/graphql", "token", http.StatusOK},
+		{http.MethodGet, "/graphql", "token", http.StatusTooManyRequests},
+		{http.MethodGet, "/books", "token", http.StatusTooManyRequests},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("request %d, %s %s: status %d, want %d", i, tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
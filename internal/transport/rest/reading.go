@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) getReadingSessions(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getReadingSessions", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := h.readingService.GetSessions(r.Context(), bookID)
+	if err != nil {
+		logError("getReadingSessions", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getReadingSessions", http.StatusOK, sessions)
+}
+
+func (h *Handler) recordReadingSession(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("recordReadingSession", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("recordReadingSession", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ReadingSessionInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("recordReadingSession", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("recordReadingSession", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.readingService.RecordSession(r.Context(), bookID, inp)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("recordReadingSession", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "recordReadingSession", http.StatusCreated, session)
+}
+
+func (h *Handler) deleteReadingSession(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteReadingSession", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["sessionID"], 10, 64)
+	if err != nil {
+		logError("deleteReadingSession", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.readingService.DeleteSession(r.Context(), bookID, id); err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("deleteReadingSession", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) getMyStats(w http.ResponseWriter, r *http.Request) {
+	var year int
+	if v := r.URL.Query().Get("year"); v != "" {
+		var err error
+		if year, err = strconv.Atoi(v); err != nil || year < 1900 || year > 3000 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := h.readingService.Stats(r.Context(), year)
+	if err != nil {
+		logError("getMyStats", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getMyStats", http.StatusOK, stats)
+}
+
+func (h *Handler) setReadingGoal(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("setReadingGoal", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ReadingGoalInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("setReadingGoal", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("setReadingGoal", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.readingService.SetGoal(r.Context(), inp); err != nil {
+		logError("setReadingGoal", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,227 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) getBookReviews(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getBookReviews", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := getPageFromRequest(r)
+	if err != nil {
+		logError("getBookReviews", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	sort := domain.ReviewSort(r.URL.Query().Get("sort"))
+	if sort != "" && sort != domain.ReviewsRecent && sort != domain.ReviewsHelpful {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.reviewsService.List(r.Context(), bookID, domain.ReviewFilter{
+		Sort:   sort,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getBookReviews", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getBookReviews", http.StatusOK, reviews)
+}
+
+func (h *Handler) getMyReview(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getMyReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.reviewsService.GetMine(r.Context(), bookID)
+	if err != nil {
+		if errors.Is(err, domain.ErrReviewNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("getMyReview", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getMyReview", http.StatusOK, review)
+}
+
+func (h *Handler) saveMyReview(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("saveMyReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("saveMyReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ReviewInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("saveMyReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("saveMyReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.reviewsService.Save(r.Context(), bookID, inp)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("saveMyReview", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "saveMyReview", http.StatusOK, review)
+}
+
+func (h *Handler) deleteMyReview(w http.ResponseWriter, r *http.Request) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteMyReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.reviewsService.DeleteMine(r.Context(), bookID)
+	if err != nil {
+		if errors.Is(err, domain.ErrReviewNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logError("deleteMyReview", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) voteReview(w http.ResponseWriter, r *http.Request) {
+	h.reviewAction(w, r, "voteReview", h.reviewsService.Vote)
+}
+
+func (h *Handler) unvoteReview(w http.ResponseWriter, r *http.Request) {
+	h.reviewAction(w, r, "unvoteReview", h.reviewsService.Unvote)
+}
+
+func (h *Handler) hideReview(w http.ResponseWriter, r *http.Request) {
+	h.reviewAction(w, r, "hideReview", func(ctx context.Context, bookID, reviewID int64) error {
+		return h.reviewsService.SetHidden(ctx, bookID, reviewID, true)
+	})
+}
+
+func (h *Handler) unhideReview(w http.ResponseWriter, r *http.Request) {
+	h.reviewAction(w, r, "unhideReview", func(ctx context.Context, bookID, reviewID int64) error {
+		return h.reviewsService.SetHidden(ctx, bookID, reviewID, false)
+	})
+}
+
+func (h *Handler) reportReview(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("reportReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ReportReviewInput
+	if len(reqBytes) > 0 {
+		if err = json.Unmarshal(reqBytes, &inp); err != nil {
+			logError("reportReview", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("reportReview", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.reviewAction(w, r, "reportReview", func(ctx context.Context, bookID, reviewID int64) error {
+		return h.reviewsService.Report(ctx, bookID, reviewID, inp)
+	})
+}
+
+// reviewAction runs an action without a response body on the review
+// addressed by the request path.
+func (h *Handler) reviewAction(w http.ResponseWriter, r *http.Request, handler string,
+	action func(ctx context.Context, bookID, reviewID int64) error) {
+	bookID, err := getIdFromRequest(r)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reviewID, err := strconv.ParseInt(mux.Vars(r)["reviewID"], 10, 64)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = action(r.Context(), bookID, reviewID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrReviewNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, domain.ErrOwnReviewVote):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			logError(handler, err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,273 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) getShelves(w http.ResponseWriter, r *http.Request) {
+	shelves, err := h.shelvesService.GetAll(r.Context())
+	if err != nil {
+		logError("getShelves", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getShelves", http.StatusOK, shelves)
+}
+
+func (h *Handler) createShelf(w http.ResponseWriter, r *http.Request) {
+	inp, ok := readShelfInput(w, r, "createShelf")
+	if !ok {
+		return
+	}
+
+	shelf, err := h.shelvesService.Create(r.Context(), inp)
+	if err != nil {
+		handleShelfError(w, "createShelf", err)
+		return
+	}
+
+	writeJSON(w, "createShelf", http.StatusCreated, shelf)
+}
+
+func (h *Handler) getShelf(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	shelf, err := h.shelvesService.GetByID(r.Context(), id)
+	if err != nil {
+		handleShelfError(w, "getShelf", err)
+		return
+	}
+
+	writeJSON(w, "getShelf", http.StatusOK, shelf)
+}
+
+func (h *Handler) getSharedShelf(w http.ResponseWriter, r *http.Request) {
+	shelf, err := h.shelvesService.GetShared(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		handleShelfError(w, "getSharedShelf", err)
+		return
+	}
+
+	writeJSON(w, "getSharedShelf", http.StatusOK, shelf)
+}
+
+func (h *Handler) renameShelf(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("renameShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	inp, ok := readShelfInput(w, r, "renameShelf")
+	if !ok {
+		return
+	}
+
+	if err := h.shelvesService.Rename(r.Context(), id, inp); err != nil {
+		handleShelfError(w, "renameShelf", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteShelf(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.shelvesService.Delete(r.Context(), id); err != nil {
+		handleShelfError(w, "deleteShelf", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) addShelfBook(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("addShelfBook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("addShelfBook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ShelfBookInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("addShelfBook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("addShelfBook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.shelvesService.AddBook(r.Context(), id, inp.BookID); err != nil {
+		handleShelfError(w, "addShelfBook", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) removeShelfBook(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("removeShelfBook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	bookID, err := strconv.ParseInt(mux.Vars(r)["bookID"], 10, 64)
+	if err != nil {
+		logError("removeShelfBook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.shelvesService.RemoveBook(r.Context(), id, bookID); err != nil {
+		handleShelfError(w, "removeShelfBook", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) reorderShelf(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("reorderShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("reorderShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ReorderShelfInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("reorderShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("reorderShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.shelvesService.Reorder(r.Context(), id, inp.BookIDs); err != nil {
+		handleShelfError(w, "reorderShelf", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) shareShelf(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("shareShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	slug, err := h.shelvesService.Share(r.Context(), id)
+	if err != nil {
+		handleShelfError(w, "shareShelf", err)
+		return
+	}
+
+	writeJSON(w, "shareShelf", http.StatusOK, map[string]string{
+		"slug": slug,
+		"path": "/public/shelves/" + slug,
+	})
+}
+
+func (h *Handler) unshareShelf(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("unshareShelf", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.shelvesService.Unshare(r.Context(), id); err != nil {
+		handleShelfError(w, "unshareShelf", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func readShelfInput(w http.ResponseWriter, r *http.Request, handler string) (domain.ShelfInput, bool) {
+	var inp domain.ShelfInput
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return inp, false
+	}
+
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return inp, false
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return inp, false
+	}
+
+	return inp, true
+}
+
+func handleShelfError(w http.ResponseWriter, handler string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrShelfNotFound), errors.Is(err, domain.ErrBookNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, domain.ErrShelfExists), errors.Is(err, domain.ErrReservedShelfName):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, domain.ErrStatusShelf), errors.Is(err, domain.ErrShelfOrderMismatch):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		logError(handler, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
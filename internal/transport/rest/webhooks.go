@@ -0,0 +1,206 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/crud-app/internal/domain"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhooksService.GetAll(r.Context())
+	if err != nil {
+		logError("getWebhooks", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "getWebhooks", http.StatusOK, subs)
+}
+
+func (h *Handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("createWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.WebhookInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("createWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("createWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.webhooksService.Create(r.Context(), inp)
+	if err != nil {
+		logError("createWebhook", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, "createWebhook", http.StatusCreated, sub)
+}
+
+func (h *Handler) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.webhooksService.GetByID(r.Context(), id)
+	if err != nil {
+		handleWebhookError(w, "getWebhook", err)
+		return
+	}
+
+	writeJSON(w, "getWebhook", http.StatusOK, sub)
+}
+
+func (h *Handler) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("updateWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logError("updateWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.UpdateWebhookInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("updateWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("updateWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhooksService.Update(r.Context(), id, inp); err != nil {
+		handleWebhookError(w, "updateWebhook", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteWebhook", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhooksService.Delete(r.Context(), id); err != nil {
+		handleWebhookError(w, "deleteWebhook", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getWebhookDeliveries", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := getPageFromRequest(r)
+	if err != nil {
+		logError("getWebhookDeliveries", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.webhooksService.Deliveries(r.Context(), id, limit, offset)
+	if err != nil {
+		handleWebhookError(w, "getWebhookDeliveries", err)
+		return
+	}
+
+	writeJSON(w, "getWebhookDeliveries", http.StatusOK, deliveries)
+}
+
+func (h *Handler) getWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := getDeliveryIDs(w, r, "getWebhookDelivery")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhooksService.GetDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		handleWebhookError(w, "getWebhookDelivery", err)
+		return
+	}
+
+	writeJSON(w, "getWebhookDelivery", http.StatusOK, delivery)
+}
+
+func (h *Handler) redeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := getDeliveryIDs(w, r, "redeliverWebhook")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhooksService.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		handleWebhookError(w, "redeliverWebhook", err)
+		return
+	}
+
+	writeJSON(w, "redeliverWebhook", http.StatusAccepted, delivery)
+}
+
+func getDeliveryIDs(w http.ResponseWriter, r *http.Request, handler string) (int64, int64, bool) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	deliveryID, err := strconv.ParseInt(mux.Vars(r)["deliveryID"], 10, 64)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return id, deliveryID, true
+}
+
+func handleWebhookError(w http.ResponseWriter, handler string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrWebhookNotFound), errors.Is(err, domain.ErrDeliveryNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		logError(handler, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX books_deleted_at_idx ON books (deleted_at) WHERE deleted_at IS NOT NULL;
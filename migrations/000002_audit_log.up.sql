@@ -0,0 +1,30 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user';
+
+CREATE TABLE audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    actor_id   BIGINT NULL,
+    action     VARCHAR(64) NOT NULL,
+    entity     VARCHAR(64) NOT NULL,
+    entity_id  BIGINT NULL,
+    ip         VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    before     JSONB NULL,
+    after      JSONB NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_id, created_at);
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, created_at);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- the audit log is append-only
+CREATE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
@@ -0,0 +1,14 @@
+CREATE TABLE book_revisions (
+    id         BIGSERIAL PRIMARY KEY,
+    book_id    BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    revision   INT NOT NULL,
+    snapshot   JSONB NOT NULL,
+    author_id  BIGINT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (book_id, revision)
+);
+
+-- existing books start their history from their current state
+INSERT INTO book_revisions (book_id, revision, snapshot)
+SELECT id, 1, json_build_object('id', id, 'title', title, 'author', author, 'publish_date', publish_date, 'rating', rating)
+FROM books;
@@ -0,0 +1,33 @@
+CREATE TABLE authors (
+    id              BIGSERIAL PRIMARY KEY,
+    name            VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL UNIQUE,
+    bio             TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE book_authors (
+    book_id   BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id BIGINT NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    role      VARCHAR(16) NOT NULL DEFAULT 'author',
+    position  INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id, role)
+);
+
+CREATE INDEX book_authors_author_idx ON book_authors (author_id);
+
+-- one author per distinct spelling, ignoring case and extra whitespace
+INSERT INTO authors (name, normalized_name)
+SELECT DISTINCT ON (normalized) name, normalized
+FROM (
+    SELECT btrim(regexp_replace(author, '\s+', ' ', 'g')) AS name,
+           lower(btrim(regexp_replace(author, '\s+', ' ', 'g'))) AS normalized
+    FROM books
+    WHERE btrim(author) <> ''
+) AS spellings
+ORDER BY normalized, name;
+
+INSERT INTO book_authors (book_id, author_id, role)
+SELECT b.id, a.id, 'author'
+FROM books b
+JOIN authors a ON a.normalized_name = lower(btrim(regexp_replace(b.author, '\s+', ' ', 'g')));
@@ -0,0 +1,31 @@
+CREATE TABLE genres (
+    id        BIGSERIAL PRIMARY KEY,
+    name      VARCHAR(255) NOT NULL,
+    slug      VARCHAR(255) NOT NULL UNIQUE,
+    parent_id BIGINT NULL REFERENCES genres (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX genres_parent_idx ON genres (parent_id);
+
+CREATE TABLE book_genres (
+    book_id  BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    genre_id BIGINT NOT NULL REFERENCES genres (id) ON DELETE RESTRICT,
+    PRIMARY KEY (book_id, genre_id)
+);
+
+CREATE INDEX book_genres_genre_idx ON book_genres (genre_id);
+
+CREATE TABLE tags (
+    id   BIGSERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE book_tags (
+    book_id    BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    tag_id     BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    added_by   BIGINT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (book_id, tag_id)
+);
+
+CREATE INDEX book_tags_tag_idx ON book_tags (tag_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS books_isbn13_key;
+
+ALTER TABLE books DROP COLUMN IF EXISTS isbn13;
+ALTER TABLE books DROP COLUMN IF EXISTS isbn10;
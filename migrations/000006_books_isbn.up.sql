@@ -0,0 +1,5 @@
+ALTER TABLE books ADD COLUMN isbn10 VARCHAR(10) NULL;
+ALTER TABLE books ADD COLUMN isbn13 VARCHAR(13) NULL;
+
+-- trashed books don't hold on to their ISBN
+CREATE UNIQUE INDEX books_isbn13_key ON books (isbn13) WHERE deleted_at IS NULL;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS review_reports;
+DROP TABLE IF EXISTS review_votes;
+DROP TABLE IF EXISTS reviews;
+
+ALTER TABLE books DROP COLUMN IF EXISTS ratings_histogram;
+ALTER TABLE books DROP COLUMN IF EXISTS ratings_sum;
+ALTER TABLE books DROP COLUMN IF EXISTS ratings_count;
+
+UPDATE books SET legacy_rating = 0 WHERE legacy_rating IS NULL;
+ALTER TABLE books ALTER COLUMN legacy_rating SET NOT NULL;
+ALTER TABLE books RENAME COLUMN legacy_rating TO rating;
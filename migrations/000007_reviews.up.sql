@@ -0,0 +1,39 @@
+-- the single rating column could be overwritten by anyone, ratings now come
+-- from reviews; the old values are kept aside rather than dropped
+ALTER TABLE books RENAME COLUMN rating TO legacy_rating;
+ALTER TABLE books ALTER COLUMN legacy_rating DROP NOT NULL;
+
+ALTER TABLE books ADD COLUMN ratings_count INT NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN ratings_sum INT NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN ratings_histogram INT[] NOT NULL DEFAULT '{0,0,0,0,0}';
+
+CREATE TABLE reviews (
+    id            BIGSERIAL PRIMARY KEY,
+    book_id       BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    user_id       BIGINT NOT NULL,
+    rating        SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text          TEXT NOT NULL DEFAULT '',
+    helpful_count INT NOT NULL DEFAULT 0,
+    report_count  INT NOT NULL DEFAULT 0,
+    hidden        BOOLEAN NOT NULL DEFAULT false,
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (book_id, user_id)
+);
+
+CREATE INDEX reviews_book_idx ON reviews (book_id, created_at DESC) WHERE NOT hidden;
+CREATE INDEX reviews_reported_idx ON reviews (report_count DESC) WHERE report_count > 0 AND NOT hidden;
+
+CREATE TABLE review_votes (
+    review_id BIGINT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    user_id   BIGINT NOT NULL,
+    PRIMARY KEY (review_id, user_id)
+);
+
+CREATE TABLE review_reports (
+    review_id  BIGINT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL,
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (review_id, user_id)
+);
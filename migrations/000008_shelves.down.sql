@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shelf_entries;
+DROP TABLE IF EXISTS shelves;
@@ -0,0 +1,22 @@
+CREATE TABLE shelves (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL,
+    name       VARCHAR(255) NOT NULL,
+    kind       VARCHAR(16) NOT NULL DEFAULT 'custom',
+    share_slug VARCHAR(32) NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (user_id, name)
+);
+
+-- every user has at most one shelf of each reading status
+CREATE UNIQUE INDEX shelves_user_status_key ON shelves (user_id, kind) WHERE kind <> 'custom';
+
+CREATE TABLE shelf_entries (
+    shelf_id BIGINT NOT NULL REFERENCES shelves (id) ON DELETE CASCADE,
+    book_id  BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    added_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (shelf_id, book_id)
+);
+
+CREATE INDEX shelf_entries_book_idx ON shelf_entries (book_id);
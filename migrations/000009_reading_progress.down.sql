@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reading_goals;
+DROP TABLE IF EXISTS reading_sessions;
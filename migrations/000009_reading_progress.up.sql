@@ -0,0 +1,22 @@
+CREATE TABLE reading_sessions (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL,
+    book_id     BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    pages       INT NULL CHECK (pages >= 0),
+    percent     NUMERIC(5, 2) NULL CHECK (percent BETWEEN 0 AND 100),
+    finished    BOOLEAN NOT NULL DEFAULT false,
+    started_at  TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    CHECK (finished_at >= started_at),
+    CHECK (pages IS NOT NULL OR percent IS NOT NULL)
+);
+
+CREATE INDEX reading_sessions_user_idx ON reading_sessions (user_id, finished_at);
+CREATE INDEX reading_sessions_book_idx ON reading_sessions (book_id, user_id);
+
+CREATE TABLE reading_goals (
+    user_id BIGINT NOT NULL,
+    year    INT NOT NULL,
+    books   INT NOT NULL CHECK (books > 0),
+    PRIMARY KEY (user_id, year)
+);
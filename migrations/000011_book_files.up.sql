@@ -0,0 +1,14 @@
+CREATE TABLE book_files (
+    id           BIGSERIAL PRIMARY KEY,
+    book_id      BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    user_id      BIGINT NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    size         BIGINT NOT NULL,
+    sha256       CHAR(64) NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX book_files_book_user_idx ON book_files (book_id, user_id);
+-- blobs are shared by every file with the same content
+CREATE INDEX book_files_sha256_idx ON book_files (sha256);
@@ -0,0 +1,40 @@
+-- events are written here in the same transaction as the book change they
+-- describe, and fanned out to the subscriptions afterwards
+CREATE TABLE outbox_events (
+    id            BIGSERIAL PRIMARY KEY,
+    type          VARCHAR(64) NOT NULL,
+    data          JSONB NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMP NULL
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE webhook_subscriptions (
+    id         BIGSERIAL PRIMARY KEY,
+    url        VARCHAR(2048) NOT NULL,
+    secret     VARCHAR(255) NOT NULL,
+    events     TEXT[] NOT NULL,
+    active     BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    subscription_id  BIGINT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id         BIGINT NOT NULL,
+    event_type       VARCHAR(64) NOT NULL,
+    payload          JSONB NOT NULL,
+    status           VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts         INT NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP NOT NULL DEFAULT now(),
+    last_status_code INT NULL,
+    last_error       TEXT NULL,
+    redelivery_of    BIGINT NULL REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT now(),
+    delivered_at     TIMESTAMP NULL
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id DESC);
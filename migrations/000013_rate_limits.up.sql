@@ -0,0 +1,10 @@
+-- token buckets shared by all the instances of the service, see
+-- pkg/ratelimit. A row can be dropped once full_at has passed.
+CREATE TABLE rate_limits (
+    key        VARCHAR(255) PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    full_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX rate_limits_full_at_idx ON rate_limits (full_at);
@@ -0,0 +1,8 @@
+-- failed sign-in attempts, counted per account ("email:...") and per IP
+-- address ("ip:..."). Rows are cleared by a successful sign-in or an admin.
+CREATE TABLE login_failures (
+    key            VARCHAR(320) PRIMARY KEY,
+    failures       INT NOT NULL,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until   TIMESTAMP NULL
+);
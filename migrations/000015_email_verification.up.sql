@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMP NULL;
+
+-- accounts created before verification existed are trusted as they are
+UPDATE users SET verified_at = registered_at;
+
+CREATE TABLE email_verifications (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    nonce      VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX email_verifications_user_idx ON email_verifications (user_id, created_at);
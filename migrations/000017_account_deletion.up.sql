@@ -0,0 +1,4 @@
+-- accounts are deleted for good once delete_at has passed
+ALTER TABLE users ADD COLUMN delete_at TIMESTAMP NULL;
+
+CREATE INDEX users_delete_at_idx ON users (delete_at) WHERE delete_at IS NOT NULL;
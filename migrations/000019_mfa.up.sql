@@ -0,0 +1,17 @@
+-- TOTP setups. A row without confirmed_at is an enrollment the user hasn't
+-- confirmed yet, which doesn't protect sign-in.
+CREATE TABLE user_mfa (
+    user_id      BIGINT PRIMARY KEY,
+    secret       VARCHAR(64) NOT NULL,
+    confirmed_at TIMESTAMP NULL,
+    last_counter BIGINT NOT NULL DEFAULT 0,
+    created_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- one-time recovery codes, stored as SHA-256 hashes
+CREATE TABLE mfa_recovery_codes (
+    user_id   BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at   TIMESTAMP NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
@@ -0,0 +1 @@
+-- the renamed custom shelves keep their new names
@@ -0,0 +1,4 @@
+-- custom shelves named like a reading status shelf kept the user from getting
+-- that status shelf; the names are reserved now
+UPDATE shelves SET name = name || ' (custom)'
+WHERE kind = 'custom' AND lower(name) IN ('to read', 'reading', 'read');
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+func (c *Client) SignUp(ctx context.Context, inp SignUpInput) error {
+	req, err := newRequest(http.MethodPost, "/auth/sign-up", inp)
+	if err != nil {
+		return err
+	}
+
+	req.public = true
+
+	return c.do(ctx, req, nil)
+}
+
+// SignIn returns the access and refresh tokens of the user and makes the
+// client use them from now on. Users having two-factor authentication
+// enabled get a *MFARequiredError instead, see VerifyMFA.
+func (c *Client) SignIn(ctx context.Context, inp SignInInput) (string, string, error) {
+	req, err := newRequest(http.MethodGet, "/auth/sign-in", inp)
+	if err != nil {
+		return "", "", err
+	}
+
+	req.public = true
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	accessToken, refreshToken, err := readTokens(resp)
+	if err != nil {
+		return "", "", err
+	}
+
+	c.SetTokens(accessToken, refreshToken)
+
+	return accessToken, refreshToken, nil
+}
+
+// VerifyMFA completes a sign-in that returned a *MFARequiredError, given a
+// code from the authenticator app or a recovery code, and makes the client
+// use the tokens from now on.
+func (c *Client) VerifyMFA(ctx context.Context, mfaToken, code string) (string, string, error) {
+	req, err := newRequest(http.MethodPost, "/auth/mfa", map[string]string{
+		"mfa_token": mfaToken,
+		"code":      code,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	req.public = true
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	accessToken, refreshToken, err := readTokens(resp)
+	if err != nil {
+		return "", "", err
+	}
+
+	c.SetTokens(accessToken, refreshToken)
+
+	return accessToken, refreshToken, nil
+}
+
+// RefreshTokens renews the token pair right away. There is usually no need
+// to call it, as the client refreshes expired access tokens by itself.
+func (c *Client) RefreshTokens(ctx context.Context) (string, string, error) {
+	stale, _ := c.Tokens()
+
+	if _, err := c.refresh(ctx, stale); err != nil {
+		return "", "", err
+	}
+
+	accessToken, refreshToken := c.Tokens()
+
+	return accessToken, refreshToken, nil
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func bookPath(id int64, parts ...string) string {
+	path := "/books/" + strconv.FormatInt(id, 10)
+	for _, part := range parts {
+		path += "/" + url.PathEscape(part)
+	}
+
+	return path
+}
+
+func (c *Client) CreateBook(ctx context.Context, book Book) error {
+	req, err := newRequest(http.MethodPost, "/books", book)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, req, nil)
+}
+
+// GetBookByID returns the book. Note that the API answers with 400 rather than
+// 404 for unknown IDs.
+func (c *Client) GetBookByID(ctx context.Context, id int64) (Book, error) {
+	var book Book
+	err := c.do(ctx, request{method: http.MethodGet, path: bookPath(id)}, &book)
+
+	return book, err
+}
+
+func (c *Client) GetBookByISBN(ctx context.Context, isbn string) (Book, error) {
+	var book Book
+	err := c.do(ctx, request{method: http.MethodGet, path: "/books/isbn/" + url.PathEscape(isbn)}, &book)
+
+	return book, err
+}
+
+func (c *Client) GetBooks(ctx context.Context, filter BookFilter) ([]Book, error) {
+	var books []Book
+	err := c.do(ctx, request{method: http.MethodGet, path: "/books", query: filter.values()}, &books)
+
+	return books, err
+}
+
+// ExportBooks streams the matching books to fn one at a time, so that the
+// whole catalogue never has to fit in memory. An error returned by fn stops
+// the export and is returned as is.
+func (c *Client) ExportBooks(ctx context.Context, filter BookFilter, fn func(Book) error) error {
+	query := filter.values()
+	query.Set("format", "ndjson")
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/books/export", query: query})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var book Book
+		if err := dec.Decode(&book); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("decode response: %w", err)
+		}
+
+		if err := fn(book); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) UpdateBook(ctx context.Context, id int64, inp UpdateBookInput) error {
+	req, err := newRequest(http.MethodPut, bookPath(id), inp)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, req, nil)
+}
+
+// DeleteBook moves the book to the trash.
+func (c *Client) DeleteBook(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: bookPath(id)}, nil)
+}
+
+func (c *Client) GetTrash(ctx context.Context) ([]Book, error) {
+	var books []Book
+	err := c.do(ctx, request{method: http.MethodGet, path: "/books/trash"}, &books)
+
+	return books, err
+}
+
+func (c *Client) RestoreBook(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodPost, path: bookPath(id, "restore")}, nil)
+}
+
+func (c *Client) GetBookRevisions(ctx context.Context, id int64) ([]BookRevision, error) {
+	var revisions []BookRevision
+	err := c.do(ctx, request{method: http.MethodGet, path: bookPath(id, "revisions")}, &revisions)
+
+	return revisions, err
+}
+
+func (c *Client) GetBookRevision(ctx context.Context, id int64, revision int) (BookRevision, error) {
+	var rev BookRevision
+	err := c.do(ctx, request{method: http.MethodGet, path: bookPath(id, "revisions", strconv.Itoa(revision))}, &rev)
+
+	return rev, err
+}
+
+func (c *Client) DiffBookRevisions(ctx context.Context, id int64, from, to int) (BookRevisionDiff, error) {
+	query := url.Values{}
+	query.Set("from", strconv.Itoa(from))
+	query.Set("to", strconv.Itoa(to))
+
+	var diff BookRevisionDiff
+	err := c.do(ctx, request{method: http.MethodGet, path: bookPath(id, "revisions", "diff"), query: query}, &diff)
+
+	return diff, err
+}
+
+// RevertBookToRevision returns the number of the revision created by the
+// revert.
+func (c *Client) RevertBookToRevision(ctx context.Context, id int64, revision int) (int, error) {
+	var resp struct {
+		Revision int `json:"revision"`
+	}
+
+	req := request{method: http.MethodPost, path: bookPath(id, "revisions", strconv.Itoa(revision), "revert")}
+	err := c.do(ctx, req, &resp)
+
+	return resp.Revision, err
+}
+
+func (c *Client) GetBookFacets(ctx context.Context, filter BookFilter) (BookFacets, error) {
+	var facets BookFacets
+	err := c.do(ctx, request{method: http.MethodGet, path: "/books/facets", query: filter.values()}, &facets)
+
+	return facets, err
+}
+
+func (c *Client) SetBookGenres(ctx context.Context, id int64, genreIDs []int64) error {
+	req, err := newRequest(http.MethodPut, bookPath(id, "genres"), map[string][]int64{
+		"genre_ids": genreIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, req, nil)
+}
+
+func (c *Client) GetBookTags(ctx context.Context, id int64) ([]string, error) {
+	var tags []string
+	err := c.do(ctx, request{method: http.MethodGet, path: bookPath(id, "tags")}, &tags)
+
+	return tags, err
+}
+
+func (c *Client) AddBookTags(ctx context.Context, id int64, tags []string) error {
+	req, err := newRequest(http.MethodPost, bookPath(id, "tags"), map[string][]string{
+		"tags": tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, req, nil)
+}
+
+func (c *Client) RemoveBookTag(ctx context.Context, id int64, tag string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: bookPath(id, "tags", tag)}, nil)
+}
@@ -0,0 +1,369 @@
+// Package client is a typed Go client for the REST API of the service.
+//
+// A Client keeps the access and refresh tokens of one user. Once signed in,
+// it renews the access token with the refresh token whenever the API
+// rejects it, and retries idempotent requests that fail for transient
+// reasons.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const refreshCookie = "refresh-token"
+
+type Config struct {
+	// BaseURL is where the API is served, e.g. http://localhost:8080.
+	BaseURL string
+	// HTTPClient defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+	// MaxRetries is how many times an idempotent request is repeated after a
+	// network error or a 429, 502, 503 or 504 response. Zero means 3, a
+	// negative value disables retries.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential delay between retries.
+	// They default to 200ms and 5s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	c := &Client{
+		baseURL:    baseURL,
+		httpClient: cfg.HTTPClient,
+		maxRetries: cfg.MaxRetries,
+		minBackoff: cfg.MinBackoff,
+		maxBackoff: cfg.MaxBackoff,
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	switch {
+	case c.maxRetries == 0:
+		c.maxRetries = 3
+	case c.maxRetries < 0:
+		c.maxRetries = 0
+	}
+
+	if c.minBackoff <= 0 {
+		c.minBackoff = 200 * time.Millisecond
+	}
+
+	if c.maxBackoff < c.minBackoff {
+		c.maxBackoff = max(5*time.Second, c.minBackoff)
+	}
+
+	return c, nil
+}
+
+// SetTokens makes the client act on behalf of an already signed in user.
+func (c *Client) SetTokens(accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.accessToken, c.refreshToken = accessToken, refreshToken
+}
+
+// Tokens returns the current token pair, e.g. to persist it between runs.
+func (c *Client) Tokens() (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.accessToken, c.refreshToken
+}
+
+// request describes a call to the API. body is kept encoded, so that the
+// request can be sent again on retry or after a token refresh.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   []byte
+	// public requests are sent without the access token and never trigger
+	// a refresh.
+	public bool
+	cookie *http.Cookie
+}
+
+func newRequest(method, path string, body interface{}) (request, error) {
+	req := request{method: method, path: path}
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return req, err
+		}
+
+		req.body = b
+	}
+
+	return req, nil
+}
+
+// do sends the request and decodes a successful JSON response into out,
+// unless out is nil.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+// send returns the response of a successful request, refreshing the tokens
+// once if the access token is rejected. Error responses are turned into an
+// *Error.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	accessToken, _ := c.Tokens()
+
+	resp, err := c.sendWithRetries(ctx, req, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, refreshToken := c.Tokens(); resp.StatusCode == http.StatusUnauthorized && !req.public && refreshToken != "" {
+		resp.Body.Close()
+
+		if accessToken, err = c.refresh(ctx, accessToken); err != nil {
+			return nil, err
+		}
+
+		if resp, err = c.sendWithRetries(ctx, req, accessToken); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, newError(resp)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) sendWithRetries(ctx context.Context, req request, accessToken string) (*http.Response, error) {
+	retries := 0
+	if isIdempotent(req.method) {
+		retries = c.maxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.sendOnce(ctx, req, accessToken)
+		if attempt >= retries || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, c.maxBackoff)
+			}
+
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) sendOnce(ctx context.Context, req request, accessToken string) (*http.Response, error) {
+	u := c.baseURL.JoinPath(req.path)
+	u.RawQuery = req.query.Encode()
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	if req.cookie != nil {
+		httpReq.AddCookie(req.cookie)
+	}
+
+	if !req.public && accessToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	return c.httpClient.Do(httpReq)
+}
+
+// refresh exchanges the refresh token for a new pair. stale is the access
+// token that was rejected: if another call has replaced it in the meantime,
+// the new one is used instead of refreshing again.
+func (c *Client) refresh(ctx context.Context, stale string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != stale {
+		return c.accessToken, nil
+	}
+
+	if c.refreshToken == "" {
+		return "", &Error{StatusCode: http.StatusUnauthorized, Message: "not signed in"}
+	}
+
+	req := request{
+		method: http.MethodGet,
+		path:   "/auth/refresh",
+		public: true,
+		cookie: &http.Cookie{Name: refreshCookie, Value: c.refreshToken},
+	}
+
+	resp, err := c.sendWithRetries(ctx, req, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", newError(resp)
+	}
+
+	accessToken, refreshToken, err := readTokens(resp)
+	if err != nil {
+		return "", err
+	}
+
+	c.accessToken, c.refreshToken = accessToken, refreshToken
+
+	return accessToken, nil
+}
+
+// readTokens takes the access token from the body of a sign-in or refresh
+// response and the refresh token from its cookie.
+func readTokens(resp *http.Response) (string, string, error) {
+	var body struct {
+		Token     string    `json:"token"`
+		MFAToken  string    `json:"mfa_token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", "", fmt.Errorf("decode response: %w", err)
+	}
+
+	if body.MFAToken != "" {
+		return "", "", &MFARequiredError{Token: body.MFAToken, ExpiresAt: body.ExpiresAt}
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == refreshCookie {
+			return body.Token, cookie.Value, nil
+		}
+	}
+
+	return "", "", errors.New("response has no refresh token cookie")
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.minBackoff << attempt
+	if delay <= 0 || delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+
+	// full jitter keeps clients that failed together from retrying together
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// The API mostly reports failures by status code alone. *Error matches these
+// with errors.Is according to its status code.
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrServer          = errors.New("server error")
+)
+
+// Error is returned for responses with a 4xx or 5xx status.
+type Error struct {
+	StatusCode int
+	// Message is the "error" field of the response body when there is one,
+	// and the status text otherwise.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return "api: " + e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrTooManyRequests:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// MFARequiredError is returned by SignIn for users having two-factor
+// authentication enabled. Token is passed to VerifyMFA along with a code.
+type MFARequiredError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return "api: two-factor authentication code required"
+}
+
+func newError(resp *http.Response) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+	}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(b, &body) == nil && body.Error != "" {
+		e.Message = body.Error
+	}
+
+	return e
+}
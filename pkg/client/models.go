@@ -0,0 +1,176 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Book struct {
+	ID              int64           `json:"id"`
+	Title           string          `json:"title"`
+	Author          string          `json:"author"`
+	ISBN10          string          `json:"isbn10,omitempty"`
+	ISBN13          string          `json:"isbn13,omitempty"`
+	PublishDate     time.Time       `json:"publish_date"`
+	Rating          float64         `json:"rating"`
+	RatingCount     int             `json:"rating_count"`
+	RatingHistogram RatingHistogram `json:"rating_histogram"`
+	Authors         []BookAuthor    `json:"authors,omitempty"`
+	Genres          []Genre         `json:"genres,omitempty"`
+	Tags            []string        `json:"tags,omitempty"`
+	CoverURL        string          `json:"cover_url,omitempty"`
+	DeletedAt       *time.Time      `json:"deleted_at,omitempty"`
+}
+
+// RatingHistogram holds the number of ratings per star, from 1 to 5.
+type RatingHistogram [5]int
+
+type AuthorRole string
+
+const (
+	AuthorRoleAuthor     AuthorRole = "author"
+	AuthorRoleTranslator AuthorRole = "translator"
+	AuthorRoleEditor     AuthorRole = "editor"
+)
+
+type BookAuthor struct {
+	AuthorID int64      `json:"author_id"`
+	Name     string     `json:"name,omitempty"`
+	Role     AuthorRole `json:"role"`
+}
+
+type Genre struct {
+	ID       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	ParentID *int64  `json:"parent_id,omitempty"`
+	Children []Genre `json:"children,omitempty"`
+}
+
+// UpdateBookInput only changes the fields that are not nil.
+type UpdateBookInput struct {
+	Title       *string       `json:"title,omitempty"`
+	Author      *string       `json:"author,omitempty"`
+	PublishDate *time.Time    `json:"publish_date,omitempty"`
+	Authors     *[]BookAuthor `json:"authors,omitempty"`
+	ISBN10      *string       `json:"isbn10,omitempty"`
+	ISBN13      *string       `json:"isbn13,omitempty"`
+}
+
+type TagsMode string
+
+const (
+	TagsAny TagsMode = "any"
+	TagsAll TagsMode = "all"
+)
+
+// BookFilter narrows down book listings. Zero values mean "no restriction".
+type BookFilter struct {
+	Title           string
+	Author          string
+	AuthorID        *int64
+	GenreID         *int64
+	Tags            []string
+	TagsMode        TagsMode
+	MinRating       *int
+	MaxRating       *int
+	PublishedAfter  *time.Time
+	PublishedBefore *time.Time
+}
+
+func (f BookFilter) values() url.Values {
+	v := url.Values{}
+
+	if f.Title != "" {
+		v.Set("title", f.Title)
+	}
+
+	if f.Author != "" {
+		v.Set("author", f.Author)
+	}
+
+	if f.AuthorID != nil {
+		v.Set("author_id", strconv.FormatInt(*f.AuthorID, 10))
+	}
+
+	if f.GenreID != nil {
+		v.Set("genre_id", strconv.FormatInt(*f.GenreID, 10))
+	}
+
+	if len(f.Tags) > 0 {
+		v.Set("tags", strings.Join(f.Tags, ","))
+
+		if f.TagsMode != "" {
+			v.Set("tags_mode", string(f.TagsMode))
+		}
+	}
+
+	if f.MinRating != nil {
+		v.Set("min_rating", strconv.Itoa(*f.MinRating))
+	}
+
+	if f.MaxRating != nil {
+		v.Set("max_rating", strconv.Itoa(*f.MaxRating))
+	}
+
+	if f.PublishedAfter != nil {
+		v.Set("published_after", f.PublishedAfter.Format(time.RFC3339))
+	}
+
+	if f.PublishedBefore != nil {
+		v.Set("published_before", f.PublishedBefore.Format(time.RFC3339))
+	}
+
+	return v
+}
+
+type BookRevision struct {
+	BookID    int64     `json:"book_id"`
+	Revision  int       `json:"revision"`
+	Book      Book      `json:"book"`
+	AuthorID  int64     `json:"author_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BookFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type BookRevisionDiff struct {
+	BookID  int64             `json:"book_id"`
+	From    int               `json:"from"`
+	To      int               `json:"to"`
+	Changes []BookFieldChange `json:"changes"`
+}
+
+type GenreFacet struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id,omitempty"`
+	Count    int    `json:"count"`
+}
+
+type TagFacet struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type BookFacets struct {
+	Genres []GenreFacet `json:"genres"`
+	Tags   []TagFacet   `json:"tags"`
+}
+
+type SignUpInput struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type SignInInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
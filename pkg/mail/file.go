@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileDrop writes each message to its own .eml file under a directory, for
+// development setups without a mail server.
+type FileDrop struct {
+	dir  string
+	from string
+}
+
+func NewFileDrop(dir, from string) (*FileDrop, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileDrop{dir: dir, from: from}, nil
+}
+
+// Send writes to a temporary file first and renames it into place, so that
+// whatever watches the directory never sees a partial message.
+func (d *FileDrop) Send(ctx context.Context, msg Message) error {
+	msg, err := msg.withFrom(d.from)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := now.UTC().Format("20060102T150405.000000000") + "-" + newID() + ".eml"
+
+	tmp, err := os.CreateTemp(d.dir, ".mail-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(msg.bytes(now)); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(d.dir, name))
+}
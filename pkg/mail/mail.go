@@ -0,0 +1,83 @@
+// Package mail sends plain text emails, over SMTP or, where no mail server is
+// at hand, to files on disk or to memory.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+type Message struct {
+	// From may be left empty for the sender configured on the mailer.
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// withFrom fills in the default sender and checks the addresses, so that no
+// header can be injected through them.
+func (m Message) withFrom(from string) (Message, error) {
+	if m.From == "" {
+		m.From = from
+	}
+
+	for _, addr := range []string{m.From, m.To} {
+		if _, err := mail.ParseAddress(addr); err != nil || strings.ContainsAny(addr, "\r\n") {
+			return m, fmt.Errorf("%w: %q", ErrInvalidAddress, addr)
+		}
+	}
+
+	return m, nil
+}
+
+// bytes renders the message in the Internet Message Format.
+func (m Message) bytes(now time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", newID(), domainOf(m.From))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(m.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return buf.Bytes()
+}
+
+func domainOf(addr string) string {
+	if a, err := mail.ParseAddress(addr); err == nil {
+		addr = a.Address
+	}
+
+	_, domain, ok := strings.Cut(addr, "@")
+	if !ok {
+		return "localhost"
+	}
+
+	return domain
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
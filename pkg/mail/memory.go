@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps the messages it is given instead of sending them, so that they
+// can be inspected by tests.
+type Memory struct {
+	mu       sync.Mutex
+	from     string
+	messages []Message
+}
+
+func NewMemory(from string) *Memory {
+	return &Memory{from: from}
+}
+
+func (m *Memory) Send(ctx context.Context, msg Message) error {
+	msg, err := msg.withFrom(m.from)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
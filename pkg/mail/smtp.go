@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP hands messages over to a mail server, upgrading the connection with
+// STARTTLS when the server offers it.
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	msg, err := msg.withFrom(s.cfg.From)
+	if err != nil {
+		return err
+	}
+
+	from, _ := mail.ParseAddress(msg.From)
+	to, _ := mail.ParseAddress(msg.To)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg.bytes(time.Now())); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory drops the buckets that have filled up.
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	state  State
+	fullAt time.Time
+}
+
+// Memory keeps buckets in the process. Each instance of the service then
+// enforces limits on its own.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets:   make(map[string]memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(now)
+	}
+
+	state, res := Take(m.buckets[key].state, limit, now)
+	m.buckets[key] = memoryEntry{state: state, fullAt: now.Add(res.Reset)}
+
+	return res, nil
+}
+
+// sweep forgets full buckets, as a missing bucket counts as a full one.
+func (m *Memory) sweep(now time.Time) {
+	for key, entry := range m.buckets {
+		if !entry.fullAt.After(now) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.lastSweep = now
+}
@@ -0,0 +1,70 @@
+// Package ratelimit implements token buckets. The bucket arithmetic is kept
+// apart from where buckets are stored, so that a shared store only has to
+// load and save State atomically.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit lets Burst requests through at once and refills at Rate tokens per
+// second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Window is how long an empty bucket takes to fill up again.
+func (l Limit) Window() time.Duration {
+	return seconds(float64(l.Burst) / l.Rate)
+}
+
+// State is what stores keep per key. A zero State is a full bucket.
+type State struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket and Remaining the whole tokens left in
+	// it after this request.
+	Limit     int
+	Remaining int
+	// Reset is when the bucket will be full again, after which the key can
+	// be forgotten.
+	Reset time.Duration
+	// RetryAfter is how long to wait for a token if the request was denied.
+	RetryAfter time.Duration
+}
+
+// Take refills the bucket for the time elapsed since its last update and
+// takes a token from it if there is one.
+func Take(state State, limit Limit, now time.Time) (State, Result) {
+	burst := float64(limit.Burst)
+
+	tokens := burst
+	if !state.UpdatedAt.IsZero() {
+		elapsed := now.Sub(state.UpdatedAt).Seconds()
+		tokens = math.Min(burst, state.Tokens+math.Max(elapsed, 0)*limit.Rate)
+	}
+
+	res := Result{Limit: limit.Burst}
+
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	res.Remaining = int(tokens)
+	res.Reset = seconds((burst - tokens) / limit.Rate)
+
+	return State{Tokens: tokens, UpdatedAt: now}, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
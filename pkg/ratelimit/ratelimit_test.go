@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+	start := time.Unix(1700000000, 0)
+
+	type step struct {
+		after         time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then deny",
+			steps: []step{
+				{0, true, 2, 0, 500 * time.Millisecond},
+				{0, true, 1, 0, time.Second},
+				{0, true, 0, 0, 1500 * time.Millisecond},
+				{0, false, 0, 500 * time.Millisecond, 1500 * time.Millisecond},
+			},
+		},
+		{
+			name: "refill at rate",
+			steps: []step{
+				{0, true, 2, 0, 500 * time.Millisecond},
+				{0, true, 1, 0, time.Second},
+				{0, true, 0, 0, 1500 * time.Millisecond},
+				{500 * time.Millisecond, true, 0, 0, 1500 * time.Millisecond},
+				{250 * time.Millisecond, false, 0, 250 * time.Millisecond, 1250 * time.Millisecond},
+			},
+		},
+		{
+			name: "refill stops at burst",
+			steps: []step{
+				{0, true, 2, 0, 500 * time.Millisecond},
+				{time.Hour, true, 2, 0, 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "clock going back adds nothing",
+			steps: []step{
+				{0, true, 2, 0, 500 * time.Millisecond},
+				{0, true, 1, 0, time.Second},
+				{0, true, 0, 0, 1500 * time.Millisecond},
+				{-time.Minute, false, 0, 500 * time.Millisecond, 1500 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state State
+			now := start
+
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+
+				var res Result
+				state, res = Take(state, limit, now)
+
+				if res.Allowed != s.wantAllowed || res.Remaining != s.wantRemaining || res.Limit != limit.Burst {
+					t.Errorf("step %d: allowed %t, remaining %d, limit %d, want %t, %d, %d",
+						i, res.Allowed, res.Remaining, res.Limit, s.wantAllowed, s.wantRemaining, limit.Burst)
+				}
+
+				if !approx(res.RetryAfter, s.wantRetry) || !approx(res.Reset, s.wantReset) {
+					t.Errorf("step %d: retry after %s, reset %s, want %s, %s",
+						i, res.RetryAfter, res.Reset, s.wantRetry, s.wantReset)
+				}
+			}
+		})
+	}
+}
+
+func TestTakeRetryAfterIsEnough(t *testing.T) {
+	limit := Limit{Rate: 0.1, Burst: 1}
+	now := time.Unix(1700000000, 0)
+
+	state, _ := Take(State{}, limit, now)
+
+	state, res := Take(state, limit, now.Add(time.Second))
+	if res.Allowed {
+		t.Fatal("second request allowed")
+	}
+
+	if _, res := Take(state, limit, now.Add(time.Second+res.RetryAfter)); !res.Allowed {
+		t.Errorf("request denied after waiting RetryAfter (%s)", res.RetryAfter)
+	}
+}
+
+func TestLimitWindow(t *testing.T) {
+	if got := (Limit{Rate: 0.5, Burst: 5}).Window(); got != 10*time.Second {
+		t.Errorf("Window() = %s, want 10s", got)
+	}
+}
+
+func approx(got, want time.Duration) bool {
+	diff := got - want
+	return diff > -time.Microsecond && diff < time.Microsecond
+}
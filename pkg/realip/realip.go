@@ -0,0 +1,95 @@
+// Package realip finds the address of the client of a request that may have
+// come through reverse proxies.
+package realip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Proxies are the reverse proxies whose X-Forwarded-For headers are
+// trusted. The zero value trusts none, so that the header is ignored.
+type Proxies struct {
+	nets []*net.IPNet
+}
+
+// ParseProxies accepts IP addresses and CIDR ranges.
+func ParseProxies(addrs []string) (Proxies, error) {
+	var p Proxies
+
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "/") {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return Proxies{}, fmt.Errorf("invalid proxy address %q", addr)
+			}
+
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+
+			p.nets = append(p.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(addr)
+		if err != nil {
+			return Proxies{}, fmt.Errorf("invalid proxy range %q", addr)
+		}
+
+		p.nets = append(p.nets, n)
+	}
+
+	return p, nil
+}
+
+// ClientIP returns the address of the client given the address of the peer
+// and the X-Forwarded-For values of the request. Anybody can send the header,
+// so it is only read when the peer is a trusted proxy, and then from right
+// to left, as each proxy appends the address it got the request from: the
+// first address that isn't a trusted proxy is the client's.
+func (p Proxies) ClientIP(remoteAddr string, forwardedFor []string) string {
+	client := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		client = host
+	}
+
+	if !p.trusts(net.ParseIP(client)) {
+		return client
+	}
+
+	var hops []string
+	for _, value := range forwardedFor {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+
+		client = ip.String()
+		if !p.trusts(ip) {
+			break
+		}
+	}
+
+	return client
+}
+
+func (p Proxies) trusts(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range p.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package realip
+
+import "testing"
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseProxies([]string{"10.0.0.0/8", "192.0.2.1", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{"no proxy", "203.0.113.7:5123", nil, "203.0.113.7"},
+		{"untrusted peer sends header", "203.0.113.7:5123", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted peer", "10.1.2.3:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"trusted ipv6 peer", "[::1]:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed first hop", "10.1.2.3:443", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"proxy chain", "10.1.2.3:443", []string{"198.51.100.1, 192.0.2.1", "10.9.9.9"}, "198.51.100.1"},
+		{"only proxies", "10.1.2.3:443", []string{"10.4.4.4"}, "10.4.4.4"},
+		{"garbage hop", "10.1.2.3:443", []string{"198.51.100.1, nonsense"}, "10.1.2.3"},
+		{"trusted peer without header", "192.0.2.1:80", nil, "192.0.2.1"},
+		{"no port", "203.0.113.7", nil, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := proxies.ClientIP(tt.remoteAddr, tt.forwardedFor); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPTrustsNobodyByDefault(t *testing.T) {
+	if got := (Proxies{}).ClientIP("127.0.0.1:80", []string{"198.51.100.1"}); got != "127.0.0.1" {
+		t.Errorf("ClientIP() = %q, want the peer", got)
+	}
+}
+
+func TestParseProxies(t *testing.T) {
+	for _, addr := range []string{"10.0.0.0/33", "localhost", ""} {
+		if _, err := ParseProxies([]string{addr}); err == nil {
+			t.Errorf("ParseProxies(%q) succeeded", addr)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const attrsSuffix = ".attrs"
+
+// Local stores blobs as files under a directory. The content type of each
+// blob is kept next to it in a small JSON sidecar file.
+type Local struct {
+	dir string
+}
+
+type localAttrs struct {
+	ContentType string `json:"content_type"`
+}
+
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Local{dir: dir}, nil
+}
+
+// Put writes the blob to a temporary file first and renames it into place, so
+// readers never see a partially written blob.
+func (s *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if size >= 0 && written != size {
+		return io.ErrUnexpectedEOF
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	attrs, err := json.Marshal(localAttrs{ContentType: contentType})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+attrsSuffix, attrs, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *Local) Open(ctx context.Context, key string) (io.ReadSeekCloser, Info, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Info{}, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Info{}, err
+	}
+
+	info := Info{
+		Size:        stat.Size(),
+		ContentType: "application/octet-stream",
+		ModTime:     stat.ModTime(),
+	}
+
+	var attrs localAttrs
+	if data, err := os.ReadFile(path + attrsSuffix); err == nil && json.Unmarshal(data, &attrs) == nil && attrs.ContentType != "" {
+		info.ContentType = attrs.ContentType
+	}
+
+	return f, info, nil
+}
+
+// Delete removes the blob. Deleting a missing blob is not an error.
+func (s *Local) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Remove(path + attrsSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Local) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
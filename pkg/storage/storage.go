@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Info describes a stored blob.
+type Info struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// validKey rejects keys that could escape the store, such as absolute paths
+// or ones with ".." segments. Keys use "/" as separator on every platform.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
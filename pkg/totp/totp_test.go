@@ -0,0 +1,116 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// the SHA-1 secret of RFC 6238 appendix B, "12345678901234567890", in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC lists 8 digit codes; the 6 digit ones are their last 6 digits.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", tt.unix, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseAndPadding(t *testing.T) {
+	got, err := Code(strings.ToLower(rfcSecret)+"====", Counter(time.Unix(59, 0)))
+	if err != nil || got != "287082" {
+		t.Errorf("Code() = %q, %v, want 287082", got, err)
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code() accepted an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	counter := Counter(now)
+
+	code := func(offset int64) string {
+		c, err := Code(rfcSecret, counter+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		skew        int
+		wantOK      bool
+		wantCounter int64
+	}{
+		{"current period", code(0), 1, true, counter},
+		{"previous period within skew", code(-1), 1, true, counter - 1},
+		{"next period within skew", code(1), 1, true, counter + 1},
+		{"two periods behind", code(-2), 1, false, 0},
+		{"two periods ahead", code(2), 1, false, 0},
+		{"previous period without skew", code(-1), 0, false, 0},
+		{"current period without skew", code(0), 0, true, counter},
+		{"wrong code", "000000", 1, false, 0},
+		{"too short", code(0)[:5], 1, false, 0},
+		{"too long", code(0) + "0", 1, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || got != tt.wantCounter {
+				t.Errorf("Validate() = %d, %t, want %d, %t", got, ok, tt.wantCounter, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewSecretRoundTrips(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := Code(secret, Counter(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := Validate(secret, code, time.Now(), 1); !ok {
+		t.Error("Validate() rejected the code of a new secret")
+	}
+}
+
+func TestURI(t *testing.T) {
+	got := URI("CRUD App", "jane@example.com", rfcSecret)
+	want := "otpauth://totp/CRUD%20App:jane@example.com?algorithm=SHA1&digits=6&issuer=CRUD%20App&period=30&secret=" + rfcSecret
+
+	if got != want {
+		t.Errorf("URI() = %s, want %s", got, want)
+	}
+}